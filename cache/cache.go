@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/valkey-io/valkey-go"
+	"log/slog"
 	"os"
 	"strings"
 )
@@ -12,16 +13,26 @@ type DistributedCache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string) error
 	SetField(ctx context.Context, key string, field string, value string) error
+	// GetField returns one field of the hash at key.
+	GetField(ctx context.Context, key string, field string) (string, error)
+	// SetIfMissing sets key to value only if key does not exist, and reports whether it did.
+	SetIfMissing(ctx context.Context, key string, value string) (bool, error)
 	Delete(ctx context.Context, key string) error
 	Close()
 }
 
+// IsMissing reports whether err means the key is not in the cache.
+func IsMissing(err error) bool {
+	return valkey.IsValkeyNil(err)
+}
+
 func NewClient() (valkey.Client, error) {
 	envUrls := os.Getenv("VALKEY_URLS")
 	if envUrls == "" {
 		return nil, errors.New("VALKEY_URLS is not set")
 	}
 	urls := strings.Split(envUrls, ",")
+	slog.Debug("Connecting to cache", "addresses", urls)
 	return valkey.NewClient(valkey.ClientOption{InitAddress: urls})
 }
 
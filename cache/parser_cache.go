@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"github.com/valkey-io/valkey-go"
+	"log/slog"
 )
 
 type ParserValkeyCache struct {
@@ -10,22 +11,57 @@ type ParserValkeyCache struct {
 }
 
 func (p *ParserValkeyCache) Get(ctx context.Context, key string) (string, error) {
-	result := p.valkeyCache.Do(ctx, p.valkeyCache.B().Get().Key(key).Build())
-	return result.ToString()
+	result, err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Get().Key(key).Build()).ToString()
+	if !IsMissing(err) {
+		logFailure(ctx, "GET", key, err)
+	}
+	return result, err
 }
 
 func (p *ParserValkeyCache) Set(ctx context.Context, key, value string) error {
-	return p.valkeyCache.Do(ctx, p.valkeyCache.B().Set().Key(key).Value(value).Build()).Error()
+	err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Set().Key(key).Value(value).Build()).Error()
+	logFailure(ctx, "SET", key, err)
+	return err
+}
+
+func (p *ParserValkeyCache) SetIfMissing(ctx context.Context, key, value string) (bool, error) {
+	err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Set().Key(key).Value(value).Nx().Build()).Error()
+	// SET NX replies nil when the key already exists
+	if IsMissing(err) {
+		return false, nil
+	}
+	logFailure(ctx, "SET NX", key, err)
+	return err == nil, err
 }
 
 func (p *ParserValkeyCache) SetField(ctx context.Context, key, field, value string) error {
-	return p.valkeyCache.Do(ctx, p.valkeyCache.B().Hsetnx().Key(key).Field(field).Value(value).Build()).Error()
+	err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Hsetnx().Key(key).Field(field).Value(value).Build()).Error()
+	logFailure(ctx, "HSETNX", key, err)
+	return err
+}
+
+func (p *ParserValkeyCache) GetField(ctx context.Context, key, field string) (string, error) {
+	result, err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Hget().Key(key).Field(field).Build()).ToString()
+	if !IsMissing(err) {
+		logFailure(ctx, "HGET", key, err)
+	}
+	return result, err
 }
 
 func (p *ParserValkeyCache) Delete(ctx context.Context, key string) error {
-	return p.valkeyCache.Do(ctx, p.valkeyCache.B().Del().Key(key).Build()).Error()
+	err := p.valkeyCache.Do(ctx, p.valkeyCache.B().Del().Key(key).Build()).Error()
+	logFailure(ctx, "DEL", key, err)
+	return err
 }
 
 func (p *ParserValkeyCache) Close() {
 	p.valkeyCache.Close()
 }
+
+// logFailure logs a failed command at debug level. Callers decide whether the error matters,
+// so this only adds the command and key they may not log.
+func logFailure(ctx context.Context, command, key string, err error) {
+	if err != nil {
+		slog.DebugContext(ctx, "Cache command failed", "command", command, "key", key, "err", err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package charset detects the character encoding of a file and transcodes it to UTF-8 while it is read.
+//
+// A sequence that cannot be decoded is written as the byte 0xFF, which never occurs in UTF-8,
+// so a line holding one fails utf8.ValidString and can be rejected rather than passed on as mojibake.
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go-file-parsing/config"
+	"io"
+	"unicode/utf8"
+)
+
+// sampleSize is the number of bytes EncodingAuto inspects.
+const sampleSize = 64 * 1024
+
+// invalidByte replaces a source sequence that has no Unicode mapping.
+const invalidByte = 0xFF
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F, where Windows-1252 differs from Latin-1. Zero marks an undefined byte.
+var windows1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// Reader transcodes the bytes of an underlying reader to UTF-8.
+type Reader struct {
+	src      *bufio.Reader
+	encoding string
+	decode   func(dst, src []byte, atEOF bool) ([]byte, int)
+	in       []byte
+	out      []byte
+	err      error
+}
+
+// NewReader returns a reader of r transcoded from encoding, one of the config Encoding values, to UTF-8.
+// A byte order mark at the start of r is dropped; with config.EncodingAuto it also decides the encoding.
+func NewReader(r io.Reader, encoding string) (*Reader, error) {
+	src := bufio.NewReaderSize(r, sampleSize)
+	if encoding == "" {
+		encoding = config.EncodingUTF8
+	}
+	if encoding == config.EncodingAuto {
+		sample, err := src.Peek(sampleSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		encoding = Detect(sample)
+	}
+	// Only the mark of the encoding being read is dropped; any other is decoded as text and fails validation
+	var bom []byte
+	switch encoding {
+	case config.EncodingUTF8:
+		bom = bomUTF8
+	case config.EncodingUTF16LE:
+		bom = bomUTF16LE
+	case config.EncodingUTF16BE:
+		bom = bomUTF16BE
+	}
+	if bom != nil {
+		if start, _ := src.Peek(len(bom)); bytes.Equal(start, bom) {
+			src.Discard(len(bom))
+		}
+	}
+
+	cr := &Reader{src: src, encoding: encoding}
+	switch encoding {
+	case config.EncodingUTF8:
+	case config.EncodingLatin1:
+		cr.decode = decodeLatin1
+	case config.EncodingWindows1252:
+		cr.decode = decodeWindows1252
+	case config.EncodingUTF16LE:
+		cr.decode = func(dst, src []byte, atEOF bool) ([]byte, int) { return decodeUTF16(dst, src, atEOF, false) }
+	case config.EncodingUTF16BE:
+		cr.decode = func(dst, src []byte, atEOF bool) ([]byte, int) { return decodeUTF16(dst, src, atEOF, true) }
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	if cr.decode != nil {
+		cr.in = make([]byte, 0, 32*1024)
+	}
+	return cr, nil
+}
+
+// Encoding returns the encoding being read, as detected when the reader was created with config.EncodingAuto.
+func (r *Reader) Encoding() string {
+	return r.encoding
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.decode == nil {
+		return r.src.Read(p)
+	}
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := r.src.Read(r.in[len(r.in):cap(r.in)])
+		r.in = r.in[:len(r.in)+n]
+		r.err = err
+		var consumed int
+		r.out, consumed = r.decode(r.out[:0], r.in, err != nil)
+		// Keep the bytes of a sequence split across reads for the next one
+		r.in = r.in[:copy(r.in, r.in[consumed:])]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func decodeLatin1(dst, src []byte, _ bool) ([]byte, int) {
+	for _, b := range src {
+		dst = utf8.AppendRune(dst, rune(b))
+	}
+	return dst, len(src)
+}
+
+func decodeWindows1252(dst, src []byte, _ bool) ([]byte, int) {
+	for _, b := range src {
+		switch {
+		case b < 0x80 || b > 0x9F:
+			dst = utf8.AppendRune(dst, rune(b))
+		case windows1252[b-0x80] != 0:
+			dst = utf8.AppendRune(dst, windows1252[b-0x80])
+		default:
+			dst = append(dst, invalidByte)
+		}
+	}
+	return dst, len(src)
+}
+
+// decodeUTF16 decodes whole code units and surrogate pairs, leaving a split one for the next call unless atEOF.
+func decodeUTF16(dst, src []byte, atEOF, bigEndian bool) ([]byte, int) {
+	unit := func(i int) rune {
+		if bigEndian {
+			return rune(src[i])<<8 | rune(src[i+1])
+		}
+		return rune(src[i+1])<<8 | rune(src[i])
+	}
+	i := 0
+	for ; i+1 < len(src); i += 2 {
+		u := unit(i)
+		switch {
+		case u < 0xD800 || u > 0xDFFF:
+			dst = utf8.AppendRune(dst, u)
+		case u >= 0xDC00:
+			// A low surrogate without a high one
+			dst = append(dst, invalidByte)
+		case i+3 >= len(src) && !atEOF:
+			return dst, i
+		case i+3 < len(src) && unit(i+2) >= 0xDC00 && unit(i+2) <= 0xDFFF:
+			dst = utf8.AppendRune(dst, 0x10000+(u-0xD800)<<10+(unit(i+2)-0xDC00))
+			i += 2
+		default:
+			dst = append(dst, invalidByte)
+		}
+	}
+	if i < len(src) && atEOF {
+		// A trailing odd byte
+		dst = append(dst, invalidByte)
+		i = len(src)
+	}
+	return dst, i
+}
+
+// Detect guesses the encoding of sample, the first bytes of a file: from its byte order mark if it has one,
+// otherwise UTF-16 when most of the ASCII characters are paired with a zero byte, UTF-8 when the sample is
+// valid UTF-8, and Windows-1252 (a superset of the printable Latin-1 characters) when it is not.
+func Detect(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return config.EncodingUTF8
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return config.EncodingUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return config.EncodingUTF16BE
+	}
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 && sample[i+1] != 0 {
+			evenZeros++
+		} else if sample[i+1] == 0 && sample[i] != 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case pairs > 0 && oddZeros*2 > pairs:
+		return config.EncodingUTF16LE
+	case pairs > 0 && evenZeros*2 > pairs:
+		return config.EncodingUTF16BE
+	case utf8.Valid(trimPartialRune(sample)):
+		return config.EncodingUTF8
+	}
+	return config.EncodingWindows1252
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of a sample.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
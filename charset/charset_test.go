@@ -0,0 +1,105 @@
+package charset
+
+import (
+	"bytes"
+	"go-file-parsing/config"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func readAll(t *testing.T, src []byte, encoding string) string {
+	t.Helper()
+	// One byte at a time, so every multi-byte sequence is split across reads
+	r, err := NewReader(iotest.OneByteReader(bytes.NewReader(src)), encoding)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(out)
+}
+
+func utf16LE(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		if r >= 0x10000 {
+			r -= 0x10000
+			hi, lo := 0xD800+(r>>10), 0xDC00+(r&0x3FF)
+			b = append(b, byte(hi), byte(hi>>8), byte(lo), byte(lo>>8))
+			continue
+		}
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return b
+}
+
+func swap(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := 0; i+1 < len(b); i += 2 {
+		out[i], out[i+1] = b[i+1], b[i]
+	}
+	return out
+}
+
+func TestNewReader(t *testing.T) {
+	text := "id,emp_title\n1,Café Niño 😀\n"
+	tests := []struct {
+		name     string
+		src      []byte
+		encoding string
+		want     string
+	}{
+		{name: "utf-8 BOM stripped", src: append([]byte{0xEF, 0xBB, 0xBF}, "id,name\n"...), encoding: "", want: "id,name\n"},
+		{name: "latin-1", src: []byte("1,Caf\xe9 Ni\xf1o\n"), encoding: config.EncodingLatin1, want: "1,Café Niño\n"},
+		{name: "windows-1252", src: []byte("\x93Quoted\x94 \x80 5\n"), encoding: config.EncodingWindows1252, want: "“Quoted” € 5\n"},
+		{name: "windows-1252 undefined byte", src: []byte("a\x81b"), encoding: config.EncodingWindows1252, want: "a\xffb"},
+		{name: "utf-16le with BOM", src: append([]byte{0xFF, 0xFE}, utf16LE(text)...), encoding: config.EncodingUTF16LE, want: text},
+		{name: "utf-16be", src: swap(utf16LE(text)), encoding: config.EncodingUTF16BE, want: text},
+		{name: "utf-16 unpaired surrogate", src: []byte{'a', 0, 0x00, 0xD8, 'b', 0}, encoding: config.EncodingUTF16LE, want: "a\xffb"},
+		{name: "utf-16 trailing byte", src: []byte{'a', 0, 'b'}, encoding: config.EncodingUTF16LE, want: "a\xff"},
+		{name: "auto utf-16le", src: append([]byte{0xFF, 0xFE}, utf16LE(text)...), encoding: config.EncodingAuto, want: text},
+		{name: "auto windows-1252", src: []byte("1,Caf\xe9\n"), encoding: config.EncodingAuto, want: "1,Café\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readAll(t, tt.src, tt.encoding); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReader_UnknownEncoding(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(nil), "ebcdic"); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample []byte
+		want   string
+	}{
+		{name: "utf-8 BOM", sample: []byte("\xEF\xBB\xBFid"), want: config.EncodingUTF8},
+		{name: "utf-16le BOM", sample: []byte{0xFF, 0xFE, 'i', 0}, want: config.EncodingUTF16LE},
+		{name: "utf-16be BOM", sample: []byte{0xFE, 0xFF, 0, 'i'}, want: config.EncodingUTF16BE},
+		{name: "utf-16le without BOM", sample: utf16LE("id,name\n1,x\n"), want: config.EncodingUTF16LE},
+		{name: "utf-16be without BOM", sample: swap(utf16LE("id,name\n1,x\n")), want: config.EncodingUTF16BE},
+		{name: "ascii", sample: []byte("id,name\n"), want: config.EncodingUTF8},
+		{name: "utf-8", sample: []byte("1,Café\n"), want: config.EncodingUTF8},
+		{name: "utf-8 cut mid-rune", sample: []byte("1,Caf\xC3"), want: config.EncodingUTF8},
+		{name: "latin-1 bytes", sample: []byte("1,Caf\xe9\n"), want: config.EncodingWindows1252},
+		{name: "empty", sample: nil, want: config.EncodingUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.sample); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,267 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"go-file-parsing/utils"
+	"log/slog"
+	"math"
 	"os"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// AsOfIssueDate is the AsOfDate value that measures each row against its own issue_d column.
+const AsOfIssueDate = "issue_d"
+
+// Policies for DuplicatePolicy
+const (
+	DuplicateReject    = "reject"
+	DuplicateKeepFirst = "keep-first"
+	DuplicateKeepLast  = "keep-last"
+)
+
+// Input formats for Format
+const (
+	FormatDelimited  = "delimited"
+	FormatTSV        = "tsv"
+	FormatFixedWidth = "fixed-width"
+	FormatJSONL      = "jsonl"
+	FormatXLSX       = "xlsx"
+)
+
+// Source encodings for Encoding
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingLatin1      = "latin-1"
+	EncodingWindows1252 = "windows-1252"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingAuto        = "auto"
+)
+
+// Modes for DialectDetection
+const (
+	DialectCheck = "check"
+	DialectAuto  = "auto"
 )
 
 type ParserConfig struct {
 	Delimiter       string
 	ExpectedColumns int
 	HasHeader       bool
+	// Format selects how each line is split into columns: FormatDelimited (the default) splits on Delimiter,
+	// FormatTSV splits on tabs and decodes backslash escapes, FormatFixedWidth cuts FixedWidthFields,
+	// FormatJSONL reads one JSON object per line and FormatXLSX reads a sheet of an Excel workbook.
+	Format string `json:",omitempty"`
+	// FixedWidthFields lays out the columns of a FormatFixedWidth file, in column order.
+	FixedWidthFields []FixedWidthField `json:",omitempty"`
+	// JSONColumns lists, in column order, the key of each column in a FormatJSONL object.
+	// Keys of nested objects are dotted paths such as "applicant.fico.low". Empty uses the names in Columns.
+	JSONColumns []string `json:",omitempty"`
+	// Encoding is the character encoding of the file, which is transcoded to UTF-8 as it is read.
+	// EncodingAuto detects it from the byte order mark or the first bytes. Empty means EncodingUTF8.
+	// A byte order mark is stripped in every case. Workbooks are always read as UTF-8.
+	Encoding string `json:",omitempty"`
+	// XLSX selects the sheet and header row of a FormatXLSX workbook.
+	XLSX XLSXConfig
+	// NumberFormats overrides how numeric columns are written, keyed by header name (e.g. "int_rate").
+	NumberFormats map[string]utils.NumberFormat `json:",omitempty"`
+	// DateLayouts overrides the accepted Go time layouts for date columns, keyed by header name.
+	// Layouts are tried in order.
+	DateLayouts map[string][]string `json:",omitempty"`
+	// AsOfDate is the reference date for relative rules such as credit history length.
+	// It is either empty (the time of validation), an ISO date ("2018-12-31") or AsOfIssueDate.
+	AsOfDate string `json:",omitempty"`
+	// asOf is AsOfDate parsed by CheckAsOfDate, or the zero time when it is not a fixed date.
+	asOf time.Time
+	// EnabledRules and DisabledRules turn named validation rules on or off for a run.
+	// Rules that are in neither list use their default.
+	EnabledRules  []string `json:",omitempty"`
+	DisabledRules []string `json:",omitempty"`
+	// LicensedStates restricts addr_state to the listed postal codes. Empty allows every state.
+	LicensedStates []string `json:",omitempty"`
+	// DuplicatePolicy turns on detection of rows that repeat an earlier row's key columns, such as the loan id.
+	// Every repeat is reported as a row error; DuplicateReject drops all copies, DuplicateKeepFirst keeps the
+	// first row and DuplicateKeepLast keeps the last. Empty disables detection.
+	DuplicatePolicy string `json:",omitempty"`
+	// DuplicateCache records seen keys in the distributed cache instead of memory, so repeats are found across files.
+	DuplicateCache bool `json:",omitempty"`
+	// Aggregates configures the file-level checks run after every row has been validated.
+	Aggregates AggregateConfig
+	// DialectDetection sniffs the first lines of the file before processing. DialectCheck stops the run when
+	// Delimiter, HasHeader or ExpectedColumns disagree with the file; DialectAuto sets them from the file.
+	// Empty skips detection.
+	DialectDetection string `json:",omitempty"`
+	// Pipeline sizes the stages rows go through and bounds the memory they use.
+	Pipeline PipelineConfig
+	// Logging selects the format and level of the run's log.
+	Logging LoggingConfig
+	// Columns describes each column of the feed in order. It is written by schema inference as a starting point for rules.
+	Columns []ColumnSchema `json:",omitempty"`
+}
+
+// Column types written by schema inference
+const (
+	TypeInt     = "int"
+	TypeDecimal = "decimal"
+	TypePercent = "percent"
+	TypeDate    = "date"
+	TypeEnum    = "enum"
+	TypeText    = "text"
+)
+
+// ColumnSchema describes one column of a feed.
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// DateLayout is the Go time layout of a TypeDate column.
+	DateLayout string `json:",omitempty"`
+	// Values are the allowed values of a TypeEnum column.
+	Values []string `json:",omitempty"`
+	// Min and Max are the smallest and largest values of a numeric column, as written in the file.
+	Min string `json:",omitempty"`
+	Max string `json:",omitempty"`
+	// MaxLength is the length in bytes of the longest TypeText value.
+	MaxLength int `json:",omitempty"`
+	// SuggestedValidators describe checks worth writing for the column, e.g. "required" or "range 0 to 35".
+	SuggestedValidators []string `json:",omitempty"`
+	// Notes are observations about the sample that are not checks, e.g. "always empty in the sample".
+	Notes []string `json:",omitempty"`
+}
+
+// FixedWidthField is one column of a fixed-width record.
+type FixedWidthField struct {
+	Name string
+	// Start is the 0-based byte offset of the field and Length its width in bytes.
+	Start  int
+	Length int
+	// Trim removes the spaces padding the value.
+	Trim bool `json:",omitempty"`
+}
+
+// XLSXConfig selects what is read from a workbook.
+type XLSXConfig struct {
+	// Sheet is the name of the sheet to read. When empty, SheetIndex picks it, 0 being the first sheet.
+	Sheet      string `json:",omitempty"`
+	SheetIndex int    `json:",omitempty"`
+	// HeaderRow is the 1-based row number of the header when HasHeader is set; rows above it are skipped. Zero means 1.
+	HeaderRow int `json:",omitempty"`
+	// DateLayout is the Go time layout date cells are written in. Empty uses "2006-01-02",
+	// or "2006-01-02 15:04:05" for cells with a time of day.
+	DateLayout string `json:",omitempty"`
+}
+
+// Formats for LoggingConfig.Format
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+// LoggingConfig selects how the run logs. Every record carries the run id and the input file.
+type LoggingConfig struct {
+	// Format is LogText (the default) or LogJSON.
+	Format string `json:",omitempty"`
+	// Level is the lowest level logged: debug, info (the default), warn or error.
+	Level string `json:",omitempty"`
+	// Rows logs every row validated or rejected at debug level, and lowers Level to debug.
+	Rows bool `json:",omitempty"`
 }
 
+// SlogLevel returns the lowest level to log.
+func (l LoggingConfig) SlogLevel() (slog.Level, error) {
+	level := slog.LevelInfo
+	if l.Level != "" {
+		if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+			return level, fmt.Errorf("invalid Logging.Level %q: expected debug, info, warn or error", l.Level)
+		}
+	}
+	if l.Rows {
+		level = min(level, slog.LevelDebug)
+	}
+	return level, nil
+}
+
+// PipelineConfig sizes the read → parse → validate → write pipeline. Zero values use the defaults.
+type PipelineConfig struct {
+	// ParseWorkers split lines into columns and ValidateWorkers run the rules. Both default to the number of CPUs.
+	ParseWorkers    int `json:",omitempty"`
+	ValidateWorkers int `json:",omitempty"`
+	// WriteWorkers write records and row errors to the cache. Default 64.
+	WriteWorkers int `json:",omitempty"`
+	// QueueSize is the capacity of the channel in front of each stage. Default 1024.
+	QueueSize int `json:",omitempty"`
+	// MemoryBudgetMB bounds the estimated memory of the rows between reading and writing. Default 256.
+	MemoryBudgetMB int `json:",omitempty"`
+	// Autotune adjusts the number of active validate and write workers while the file is processed, starting from
+	// ValidateWorkers and WriteWorkers and going no higher than MaxValidateWorkers (default 4 per CPU) and
+	// MaxWriteWorkers (default 1024), nor above a heap of MaxHeapMB (default 1024).
+	Autotune           bool `json:",omitempty"`
+	MaxValidateWorkers int  `json:",omitempty"`
+	MaxWriteWorkers    int  `json:",omitempty"`
+	MaxHeapMB          int  `json:",omitempty"`
+}
+
+// Pipeline defaults
+const (
+	DefaultWriteWorkers    = 64
+	DefaultQueueSize       = 1024
+	DefaultMemoryBudgetMB  = 256
+	DefaultMaxWriteWorkers = 1024
+	DefaultMaxHeapMB       = 1024
+)
+
+// WithDefaults returns p with every unset field set to its default.
+func (p PipelineConfig) WithDefaults() PipelineConfig {
+	if p.ParseWorkers == 0 {
+		p.ParseWorkers = runtime.NumCPU()
+	}
+	if p.ValidateWorkers == 0 {
+		p.ValidateWorkers = runtime.NumCPU()
+	}
+	if p.WriteWorkers == 0 {
+		p.WriteWorkers = DefaultWriteWorkers
+	}
+	if p.QueueSize == 0 {
+		p.QueueSize = DefaultQueueSize
+	}
+	if p.MemoryBudgetMB == 0 {
+		p.MemoryBudgetMB = DefaultMemoryBudgetMB
+	}
+	if p.MaxValidateWorkers == 0 {
+		p.MaxValidateWorkers = 4 * runtime.NumCPU()
+	}
+	if p.MaxWriteWorkers == 0 {
+		p.MaxWriteWorkers = DefaultMaxWriteWorkers
+	}
+	if p.MaxHeapMB == 0 {
+		p.MaxHeapMB = DefaultMaxHeapMB
+	}
+	// The starting sizes are always allowed
+	p.MaxValidateWorkers = max(p.MaxValidateWorkers, p.ValidateWorkers)
+	p.MaxWriteWorkers = max(p.MaxWriteWorkers, p.WriteWorkers)
+	return p
+}
+
+// AggregateConfig holds the thresholds for file-level checks. A check whose thresholds are unset does not run.
+type AggregateConfig struct {
+	// MinMonthlyFunded and MaxMonthlyFunded bound the total funded_amnt of the valid loans issued in each issue_d month.
+	MinMonthlyFunded float64 `json:",omitempty"`
+	MaxMonthlyFunded float64 `json:",omitempty"`
+	// GradeBaseline is the expected share of valid loans per grade, e.g. {"A": 0.18, "B": 0.29}.
+	// Grades missing from the baseline are expected not to appear.
+	GradeBaseline map[string]float64 `json:",omitempty"`
+	// MaxGradeDrift is the largest allowed difference between a grade's share and its baseline, e.g. 0.05.
+	// It must be above 0 and at most 1 when GradeBaseline is set.
+	MaxGradeDrift float64 `json:",omitempty"`
+	// ExpectedRows is the number of data rows the file must contain, e.g. from a trailer. Zero skips the check.
+	ExpectedRows int64 `json:",omitempty"`
+}
+
+// gradeBaselineTolerance is how far the GradeBaseline shares may sum from 1, allowing for rounded shares.
+const gradeBaselineTolerance = 0.001
+
 func LoadParserConfig(filename string) (ParserConfig, error) {
 	var cfg ParserConfig
 	data, err := os.ReadFile(filename)
@@ -18,5 +270,230 @@ func LoadParserConfig(filename string) (ParserConfig, error) {
 		return cfg, err
 	}
 	err = json.Unmarshal(data, &cfg)
-	return cfg, err
+	if err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckAsOfDate(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckDuplicatePolicy(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckDialectDetection(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckFormat(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckEncoding(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckAggregates(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckPipeline(); err != nil {
+		return cfg, err
+	}
+	if err = cfg.CheckLogging(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// NumberFormat returns the configured format for a column and whether one was set.
+func (c *ParserConfig) NumberFormat(column string) (utils.NumberFormat, bool) {
+	f, ok := c.NumberFormats[column]
+	return f, ok
+}
+
+// ColumnDateLayouts returns the configured layouts for a column and whether any were set.
+func (c *ParserConfig) ColumnDateLayouts(column string) ([]string, bool) {
+	l, ok := c.DateLayouts[column]
+	return l, ok && len(l) > 0
+}
+
+// IsLicensedState reports whether loans may be made in state. Every state is allowed when LicensedStates is empty.
+func (c *ParserConfig) IsLicensedState(state string) bool {
+	return len(c.LicensedStates) == 0 || slices.Contains(c.LicensedStates, state)
+}
+
+// EnableRules turns rules on, overriding any earlier DisabledRules entry for them.
+func (c *ParserConfig) EnableRules(names ...string) {
+	c.DisabledRules = removeNames(c.DisabledRules, names)
+	c.EnabledRules = append(removeNames(c.EnabledRules, names), names...)
+}
+
+// DisableRules turns rules off, overriding any earlier EnabledRules entry for them.
+func (c *ParserConfig) DisableRules(names ...string) {
+	c.EnabledRules = removeNames(c.EnabledRules, names)
+	c.DisabledRules = append(removeNames(c.DisabledRules, names), names...)
+}
+
+func removeNames(list, names []string) []string {
+	kept := make([]string, 0, len(list))
+	for _, item := range list {
+		if !slices.Contains(names, item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// CheckAsOfDate returns an error if AsOfDate is not empty, an ISO date or AsOfIssueDate.
+// A date is parsed once here and kept for AsOf, so rules do not parse it for every row.
+func (c *ParserConfig) CheckAsOfDate() error {
+	c.asOf = time.Time{}
+	switch c.AsOfDate {
+	case "", AsOfIssueDate:
+		return nil
+	}
+	asOf, err := time.Parse(utils.ISODateLayout, c.AsOfDate)
+	if err != nil {
+		return fmt.Errorf("invalid AsOfDate %q: expected YYYY-MM-DD or %q", c.AsOfDate, AsOfIssueDate)
+	}
+	c.asOf = asOf
+	return nil
+}
+
+// AsOf returns the fixed reference date resolved by CheckAsOfDate.
+// fromIssueDate is true when each row should use its own issue_d instead.
+// When neither is set the zero time is returned and callers use the current time.
+func (c *ParserConfig) AsOf() (asOf time.Time, fromIssueDate bool) {
+	return c.asOf, c.AsOfDate == AsOfIssueDate
+}
+
+// CheckDuplicatePolicy returns an error if DuplicatePolicy is not empty or one of the known policies.
+func (c *ParserConfig) CheckDuplicatePolicy() error {
+	switch c.DuplicatePolicy {
+	case "", DuplicateReject, DuplicateKeepFirst, DuplicateKeepLast:
+		return nil
+	}
+	return fmt.Errorf("invalid DuplicatePolicy %q: expected %q, %q or %q",
+		c.DuplicatePolicy, DuplicateReject, DuplicateKeepFirst, DuplicateKeepLast)
+}
+
+// CheckDialectDetection returns an error if DialectDetection is not empty or one of the known modes.
+func (c *ParserConfig) CheckDialectDetection() error {
+	switch c.DialectDetection {
+	case "", DialectCheck, DialectAuto:
+		return nil
+	}
+	return fmt.Errorf("invalid DialectDetection %q: expected %q or %q", c.DialectDetection, DialectCheck, DialectAuto)
+}
+
+// CheckEncoding returns an error unless Encoding is empty or one of the supported encodings.
+func (c *ParserConfig) CheckEncoding() error {
+	switch c.Encoding {
+	case "", EncodingUTF8, EncodingLatin1, EncodingWindows1252, EncodingUTF16LE, EncodingUTF16BE, EncodingAuto:
+		return nil
+	}
+	return fmt.Errorf("invalid Encoding %q: expected %q, %q, %q, %q, %q or %q", c.Encoding,
+		EncodingUTF8, EncodingLatin1, EncodingWindows1252, EncodingUTF16LE, EncodingUTF16BE, EncodingAuto)
+}
+
+// CheckPipeline returns an error if a Pipeline setting is negative.
+func (c *ParserConfig) CheckPipeline() error {
+	p := c.Pipeline
+	if p.ParseWorkers < 0 || p.ValidateWorkers < 0 || p.WriteWorkers < 0 || p.QueueSize < 0 || p.MemoryBudgetMB < 0 ||
+		p.MaxValidateWorkers < 0 || p.MaxWriteWorkers < 0 || p.MaxHeapMB < 0 {
+		return fmt.Errorf("invalid Pipeline %+v: settings must not be negative", p)
+	}
+	return nil
+}
+
+// CheckAggregates returns an error if a GradeBaseline is set without a MaxGradeDrift in (0, 1],
+// or its shares are not each in [0, 1] and summing to 1.
+func (c *ParserConfig) CheckAggregates() error {
+	agg := c.Aggregates
+	if len(agg.GradeBaseline) == 0 {
+		return nil
+	}
+	if agg.MaxGradeDrift <= 0 || agg.MaxGradeDrift > 1 {
+		return fmt.Errorf("invalid Aggregates.MaxGradeDrift %v: expected more than 0 and at most 1 with a GradeBaseline", agg.MaxGradeDrift)
+	}
+	var sum float64
+	for grade, share := range agg.GradeBaseline {
+		if share < 0 || share > 1 {
+			return fmt.Errorf("invalid Aggregates.GradeBaseline share %v for grade %s: expected 0 to 1", share, grade)
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > gradeBaselineTolerance {
+		return fmt.Errorf("invalid Aggregates.GradeBaseline: shares sum to %v, expected 1", sum)
+	}
+	return nil
+}
+
+// CheckLogging returns an error unless Logging has a known format and level.
+func (c *ParserConfig) CheckLogging() error {
+	switch c.Logging.Format {
+	case "", LogText, LogJSON:
+	default:
+		return fmt.Errorf("invalid Logging.Format %q: expected %q or %q", c.Logging.Format, LogText, LogJSON)
+	}
+	_, err := c.Logging.SlogLevel()
+	return err
+}
+
+// CheckFormat returns an error if Format is unknown, a FormatFixedWidth layout is missing or has invalid fields,
+// a FormatJSONL config expects a header or names no columns, or a FormatXLSX sheet or header row is negative.
+func (c *ParserConfig) CheckFormat() error {
+	switch c.Format {
+	case "", FormatDelimited, FormatTSV:
+		return nil
+	case FormatXLSX:
+		if c.XLSX.SheetIndex < 0 || c.XLSX.HeaderRow < 0 {
+			return fmt.Errorf("format %q: XLSX.SheetIndex and XLSX.HeaderRow must not be negative", FormatXLSX)
+		}
+		return nil
+	case FormatJSONL:
+		if c.HasHeader {
+			return fmt.Errorf("format %q has no header line: set HasHeader to false", FormatJSONL)
+		}
+		if len(c.FieldNames()) == 0 {
+			return fmt.Errorf("format %q needs JSONColumns or Columns", FormatJSONL)
+		}
+		return nil
+	case FormatFixedWidth:
+		if len(c.FixedWidthFields) == 0 {
+			return fmt.Errorf("format %q needs FixedWidthFields", FormatFixedWidth)
+		}
+		for i, f := range c.FixedWidthFields {
+			if f.Start < 0 || f.Length <= 0 {
+				return fmt.Errorf("fixed-width field %d (%q): Start must not be negative and Length must be positive", i, f.Name)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid Format %q: expected %q, %q, %q, %q or %q",
+		c.Format, FormatDelimited, FormatTSV, FormatFixedWidth, FormatJSONL, FormatXLSX)
+}
+
+// Delimited reports whether Format splits lines on a delimiter, so that the dialect of the file can be sniffed.
+func (c *ParserConfig) Delimited() bool {
+	return c.Format == "" || c.Format == FormatDelimited || c.Format == FormatTSV
+}
+
+// FieldNames returns the column names the config lays out for formats without a header line:
+// the FixedWidthFields names, or the JSONColumns paths (falling back to the Columns names) for FormatJSONL.
+// It returns nil for other formats.
+func (c *ParserConfig) FieldNames() []string {
+	switch c.Format {
+	case FormatFixedWidth:
+		names := make([]string, len(c.FixedWidthFields))
+		for i, f := range c.FixedWidthFields {
+			names[i] = f.Name
+		}
+		return names
+	case FormatJSONL:
+		if len(c.JSONColumns) > 0 {
+			return c.JSONColumns
+		}
+		names := make([]string, len(c.Columns))
+		for i, col := range c.Columns {
+			names[i] = col.Name
+		}
+		return names
+	}
+	return nil
 }
@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestCheckAggregates(t *testing.T) {
+	baseline := map[string]float64{"A": 0.25, "B": 0.5, "C": 0.25}
+	testCases := []struct {
+		name    string
+		agg     AggregateConfig
+		wantErr bool
+	}{
+		{name: "no baseline", agg: AggregateConfig{ExpectedRows: 10}},
+		{name: "valid baseline", agg: AggregateConfig{GradeBaseline: baseline, MaxGradeDrift: 0.05}},
+		{name: "rounded shares", agg: AggregateConfig{GradeBaseline: map[string]float64{"A": 0.3333, "B": 0.3333, "C": 0.3333}, MaxGradeDrift: 1}},
+		{name: "zero drift", agg: AggregateConfig{GradeBaseline: baseline}, wantErr: true},
+		{name: "negative drift", agg: AggregateConfig{GradeBaseline: baseline, MaxGradeDrift: -0.05}, wantErr: true},
+		{name: "drift above 1", agg: AggregateConfig{GradeBaseline: baseline, MaxGradeDrift: 1.5}, wantErr: true},
+		{name: "negative share", agg: AggregateConfig{GradeBaseline: map[string]float64{"A": -0.5, "B": 1.5}, MaxGradeDrift: 0.05}, wantErr: true},
+		{name: "shares not summing to 1", agg: AggregateConfig{GradeBaseline: map[string]float64{"A": 0.25, "B": 0.5}, MaxGradeDrift: 0.05}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ParserConfig{Aggregates: tc.agg}
+			if err := c.CheckAggregates(); (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
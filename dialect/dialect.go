@@ -0,0 +1,227 @@
+// Package dialect sniffs the delimiter, header, line ending and byte order mark of a delimited file.
+package dialect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"io"
+	"strings"
+)
+
+// sniffBytes caps how much of the file is read, so a file without line breaks cannot exhaust memory.
+const sniffBytes = 1 << 20
+
+// Candidate delimiters, in the order ties are broken
+var delimiters = []string{",", "\t", "|", ";"}
+
+// Byte order marks
+var boms = []struct {
+	name  string
+	bytes []byte
+}{
+	{name: "UTF-8", bytes: []byte{0xEF, 0xBB, 0xBF}},
+	{name: "UTF-16LE", bytes: []byte{0xFF, 0xFE}},
+	{name: "UTF-16BE", bytes: []byte{0xFE, 0xFF}},
+}
+
+// numberFormat accepts the decorations common in exported numbers when deciding whether a cell is numeric.
+var numberFormat = utils.NumberFormat{PercentSuffix: true, CurrencyPrefix: "$", GroupingSeparator: ","}
+
+var (
+	ErrEmpty       = errors.New("file is empty")
+	ErrNoDelimiter = errors.New("no consistent delimiter found")
+	// ErrCRLineEnding is returned for lines ending in a bare "\r", which the row reader does not split on.
+	ErrCRLineEnding = errors.New(`lines end in a bare "\r", which is not supported; convert the line endings to "\n"`)
+)
+
+// Dialect describes how a delimited file is written.
+// Fields are split as the delimited decoder splits them, so values quoted with " may hold the delimiter.
+type Dialect struct {
+	Delimiter string
+	HasHeader bool
+	// Columns is the most common number of fields per line.
+	Columns int
+	// LineEnding is "\n" or "\r\n".
+	LineEnding string
+	// BOM names the byte order mark at the start of the file, e.g. "UTF-8", or is empty.
+	BOM string
+}
+
+func (d Dialect) String() string {
+	bom := d.BOM
+	if bom == "" {
+		bom = "none"
+	}
+	return fmt.Sprintf("delimiter %q, header %t, %d columns, line ending %q, BOM %s",
+		d.Delimiter, d.HasHeader, d.Columns, d.LineEnding, bom)
+}
+
+// Sniff reads up to maxLines lines from r and detects their dialect.
+func Sniff(r io.Reader, maxLines int) (Dialect, error) {
+	data, err := io.ReadAll(io.LimitReader(r, sniffBytes))
+	if err != nil {
+		return Dialect{}, err
+	}
+	var d Dialect
+	for _, bom := range boms {
+		if bytes.HasPrefix(data, bom.bytes) {
+			d.BOM = bom.name
+			data = data[len(bom.bytes):]
+			break
+		}
+	}
+	d.LineEnding = lineEnding(data)
+	if d.LineEnding == "\r" {
+		return d, ErrCRLineEnding
+	}
+	lines := sampleLines(string(data), d.LineEnding, maxLines, len(data) == sniffBytes)
+	if len(lines) == 0 {
+		return d, ErrEmpty
+	}
+
+	d.Delimiter, d.Columns = detectDelimiter(lines)
+	if d.Delimiter == "" {
+		return d, ErrNoDelimiter
+	}
+	d.HasHeader = detectHeader(lines, d.Delimiter)
+	return d, nil
+}
+
+func lineEnding(data []byte) string {
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0, data[i] == '\n':
+		return "\n"
+	case i+1 < len(data) && data[i+1] == '\n':
+		return "\r\n"
+	}
+	return "\r"
+}
+
+// sampleLines returns up to maxLines non-empty lines. When the data was cut off, the last, partial line is dropped.
+func sampleLines(data, ending string, maxLines int, truncated bool) []string {
+	lines := strings.Split(data, ending)
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	sample := make([]string, 0, maxLines)
+	for _, line := range lines {
+		if len(sample) == maxLines {
+			break
+		}
+		if line != "" {
+			sample = append(sample, line)
+		}
+	}
+	return sample
+}
+
+// splitFields splits line on delimiter the way the delimited decoder does, with surrounding whitespace and quotes removed.
+// A line the decoder cannot read has no fields.
+func splitFields(line, delimiter string) []string {
+	fields, err := validator.DelimitedDecoder{Delimiter: delimiter}.Decode(line)
+	if err != nil {
+		return nil
+	}
+	return fields
+}
+
+// detectDelimiter picks the candidate that splits the most lines into the same number of fields (more than one),
+// preferring more fields when two candidates are equally consistent.
+func detectDelimiter(lines []string) (string, int) {
+	best, bestColumns, bestMatches := "", 0, 0
+	for _, delimiter := range delimiters {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			counts[len(splitFields(line, delimiter))]++
+		}
+		columns, matches := 0, 0
+		for c, n := range counts {
+			if n > matches || (n == matches && c > columns) {
+				columns, matches = c, n
+			}
+		}
+		if columns < 2 {
+			continue
+		}
+		if matches > bestMatches || (matches == bestMatches && columns > bestColumns) {
+			best, bestColumns, bestMatches = delimiter, columns, matches
+		}
+	}
+	return best, bestColumns
+}
+
+// detectHeader votes column by column: a column whose data is numeric but whose first cell is not suggests a header,
+// and one whose first cell is numeric too suggests there is none. Without numeric columns, a first line whose
+// values all differ from the values below them is taken as a header.
+func detectHeader(lines []string, delimiter string) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	votes, numericColumns, repeats := 0, 0, 0
+	for i, name := range splitFields(lines[0], delimiter) {
+		numeric, repeated := 0, false
+		for _, line := range lines[1:] {
+			fields := splitFields(line, delimiter)
+			if i >= len(fields) {
+				continue
+			}
+			value := fields[i]
+			repeated = repeated || value == name
+			if isNumber(value) {
+				numeric++
+			}
+		}
+		if numeric*2 > len(lines)-1 {
+			numericColumns++
+			if isNumber(name) {
+				votes--
+			} else {
+				votes++
+			}
+		}
+		if name == "" || repeated {
+			repeats++
+		}
+	}
+	if numericColumns > 0 {
+		return votes > 0
+	}
+	return repeats == 0
+}
+
+func isNumber(s string) bool {
+	_, err := numberFormat.ParseDecimal(s)
+	return err == nil
+}
+
+// Mismatches describes each setting in conf that disagrees with the detected dialect.
+// A FormatTSV config is expected to use tabs, and ExpectedColumns is only compared when it is set.
+func (d Dialect) Mismatches(conf *config.ParserConfig) []string {
+	var mismatches []string
+	if conf.Format == config.FormatTSV {
+		if d.Delimiter != "\t" {
+			mismatches = append(mismatches, fmt.Sprintf("Format is %q but the file uses %q", config.FormatTSV, d.Delimiter))
+		}
+	} else if conf.Delimiter != d.Delimiter {
+		mismatches = append(mismatches, fmt.Sprintf("Delimiter is %q but the file uses %q", conf.Delimiter, d.Delimiter))
+	}
+	if conf.HasHeader != d.HasHeader {
+		mismatches = append(mismatches, fmt.Sprintf("HasHeader is %t but the file looks like HasHeader %t", conf.HasHeader, d.HasHeader))
+	}
+	if conf.ExpectedColumns > 0 && conf.ExpectedColumns != d.Columns {
+		mismatches = append(mismatches, fmt.Sprintf("ExpectedColumns is %d but the file has %d", conf.ExpectedColumns, d.Columns))
+	}
+	return mismatches
+}
+
+// Apply sets Delimiter, HasHeader and ExpectedColumns in conf from the detected dialect.
+func (d Dialect) Apply(conf *config.ParserConfig) {
+	conf.Delimiter = d.Delimiter
+	conf.HasHeader = d.HasHeader
+	conf.ExpectedColumns = d.Columns
+}
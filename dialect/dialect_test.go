@@ -0,0 +1,109 @@
+package dialect
+
+import (
+	"errors"
+	"go-file-parsing/config"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want Dialect
+	}{
+		{
+			name: "comma with header",
+			data: "id,loan_amnt,grade\n1,3600.0,C\n2,24700.0,C\n3,20000.0,B\n",
+			want: Dialect{Delimiter: ",", HasHeader: true, Columns: 3, LineEnding: "\n"},
+		},
+		{
+			name: "tab without header",
+			data: "1\t3600.0\tC\n2\t24700.0\tC\n3\t20000.0\tB\n",
+			want: Dialect{Delimiter: "\t", Columns: 3, LineEnding: "\n"},
+		},
+		{
+			name: "pipe with crlf and bom",
+			data: "\xEF\xBB\xBFid|int_rate|term\r\n1|13.56%|36 months\r\n2|11.99%|60 months\r\n",
+			want: Dialect{Delimiter: "|", HasHeader: true, Columns: 3, LineEnding: "\r\n", BOM: "UTF-8"},
+		},
+		{
+			name: "semicolon with quoted commas",
+			data: `id;title;amount` + "\n" + `1;"Debt consolidation, car";"1,000"` + "\n" + `2;"Home, garden";"2,500"` + "\n",
+			want: Dialect{Delimiter: ";", HasHeader: true, Columns: 3, LineEnding: "\n"},
+		},
+		{
+			name: "quoted comma fields",
+			data: `"id","title"` + "\n" + `"1","a, b"` + "\n" + `"2","c"` + "\n",
+			want: Dialect{Delimiter: ",", HasHeader: true, Columns: 2, LineEnding: "\n"},
+		},
+		{
+			// The decoder only reads double quotes, so a single-quoted value holding the delimiter is two columns
+			name: "single quotes are not quoting",
+			data: "id,name\n1,'Smith, J'\n2,'Lee, K'\n",
+			want: Dialect{Delimiter: ",", HasHeader: true, Columns: 3, LineEnding: "\n"},
+		},
+		{
+			name: "text only with header",
+			data: "state,status\nPA,Fully Paid\nNJ,Current\n",
+			want: Dialect{Delimiter: ",", HasHeader: true, Columns: 2, LineEnding: "\n"},
+		},
+		{
+			name: "text only without header",
+			data: "PA,Fully Paid\nNJ,Current\nPA,Current\n",
+			want: Dialect{Delimiter: ",", Columns: 2, LineEnding: "\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Sniff(strings.NewReader(tc.data), 50)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSniff_Errors(t *testing.T) {
+	if _, err := Sniff(strings.NewReader(""), 50); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected empty file error, got %v", err)
+	}
+	if _, err := Sniff(strings.NewReader("one\ntwo\nthree\n"), 50); !errors.Is(err, ErrNoDelimiter) {
+		t.Errorf("expected no delimiter error, got %v", err)
+	}
+	if _, err := Sniff(strings.NewReader("id,grade\r1,C\r2,B\r"), 50); !errors.Is(err, ErrCRLineEnding) {
+		t.Errorf("expected bare carriage return error, got %v", err)
+	}
+}
+
+func TestSniff_MaxLines(t *testing.T) {
+	// Only the first lines are read, so a later malformed line does not change the result
+	data := "a,b\n1,2\n3,4\nx;y;z;w\nx;y;z;w\nx;y;z;w\n"
+	got, err := Sniff(strings.NewReader(data), 3)
+	if err != nil || got.Delimiter != "," {
+		t.Errorf("expected comma from the first 3 lines, got %s, %v", got, err)
+	}
+}
+
+func TestMismatchesAndApply(t *testing.T) {
+	detected := Dialect{Delimiter: "\t", HasHeader: true, Columns: 151}
+	conf := &config.ParserConfig{Delimiter: ",", HasHeader: true, ExpectedColumns: 150}
+	mismatches := detected.Mismatches(conf)
+	want := []string{
+		`Delimiter is "," but the file uses "\t"`,
+		"ExpectedColumns is 150 but the file has 151",
+	}
+	if strings.Join(mismatches, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, mismatches)
+	}
+
+	detected.Apply(conf)
+	if len(detected.Mismatches(conf)) != 0 {
+		t.Errorf("expected no mismatches after Apply, got %v", detected.Mismatches(conf))
+	}
+}
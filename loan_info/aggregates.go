@@ -0,0 +1,175 @@
+package loan_info
+
+import (
+	"fmt"
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AggregateRules returns the file-level rules configured in conf.Aggregates, in the order they are checked.
+// Rules without thresholds are left out, so an empty config returns none.
+func AggregateRules(conf *config.ParserConfig) []validator.AggregateRule {
+	agg := conf.Aggregates
+	var aggregateRules []validator.AggregateRule
+	if agg.MinMonthlyFunded > 0 || agg.MaxMonthlyFunded > 0 {
+		aggregateRules = append(aggregateRules, validator.AggregateRule{
+			Name: "monthly_funded_total",
+			Aggregate: &monthlyFunded{
+				min:    agg.MinMonthlyFunded,
+				max:    agg.MaxMonthlyFunded,
+				totals: make(map[string]utils.Decimal),
+			},
+		})
+	}
+	if len(agg.GradeBaseline) > 0 {
+		aggregateRules = append(aggregateRules, validator.AggregateRule{
+			Name: "grade_distribution",
+			Aggregate: &gradeDistribution{
+				baseline: agg.GradeBaseline,
+				maxDrift: agg.MaxGradeDrift,
+				counts:   make(map[string]int64),
+			},
+		})
+	}
+	if agg.ExpectedRows > 0 {
+		aggregateRules = append(aggregateRules, validator.AggregateRule{
+			Name:      "row_count",
+			Aggregate: rowCount{expected: agg.ExpectedRows},
+		})
+	}
+	return aggregateRules
+}
+
+// Aggregate Rule: Monthly Funded Total
+// The total funded_amnt of the valid loans issued in each issue_d month is within the configured bounds.
+type monthlyFunded struct {
+	mu       sync.Mutex
+	min, max float64
+	totals   map[string]utils.Decimal
+}
+
+func (a *monthlyFunded) Add(vCtx *validator.RowValidatorContext, cols []string, _ map[string]string) {
+	_, month, err := parseDateColumn(vCtx, "issue_d", cols[colIssueD])
+	if err != nil {
+		return
+	}
+	funded, err := parseDecimalColumn(vCtx, "funded_amnt", cols[colFundingAmount])
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	a.totals[month] += funded
+	a.mu.Unlock()
+}
+
+func (a *monthlyFunded) Remove(vCtx *validator.RowValidatorContext, cols []string, _ map[string]string) {
+	_, month, err := parseDateColumn(vCtx, "issue_d", cols[colIssueD])
+	if err != nil {
+		return
+	}
+	funded, err := parseDecimalColumn(vCtx, "funded_amnt", cols[colFundingAmount])
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	a.totals[month] -= funded
+	a.mu.Unlock()
+}
+
+func (a *monthlyFunded) Check(_ validator.FileStats) error {
+	months := make([]string, 0, len(a.totals))
+	for month := range a.totals {
+		months = append(months, month)
+	}
+	slices.Sort(months)
+	for _, month := range months {
+		total := a.totals[month]
+		if a.min > 0 && total.Float64() < a.min {
+			return fmt.Errorf("%w: %s funded %s", ErrMonthlyFundedBelowMin, month, total)
+		}
+		if a.max > 0 && total.Float64() > a.max {
+			return fmt.Errorf("%w: %s funded %s", ErrMonthlyFundedAboveMax, month, total)
+		}
+	}
+	return nil
+}
+
+// Aggregate Rule: Grade Distribution
+// Each grade's share of the valid loans is within MaxGradeDrift of its baseline share.
+type gradeDistribution struct {
+	mu       sync.Mutex
+	baseline map[string]float64
+	maxDrift float64
+	counts   map[string]int64
+	total    int64
+}
+
+func (a *gradeDistribution) Add(_ *validator.RowValidatorContext, cols []string, _ map[string]string) {
+	grade := strings.ToUpper(utils.TrimIfNeeded(cols[colGrade]))
+	if grade == "" {
+		return
+	}
+	a.mu.Lock()
+	a.counts[grade]++
+	a.total++
+	a.mu.Unlock()
+}
+
+func (a *gradeDistribution) Remove(_ *validator.RowValidatorContext, cols []string, _ map[string]string) {
+	grade := strings.ToUpper(utils.TrimIfNeeded(cols[colGrade]))
+	if grade == "" {
+		return
+	}
+	a.mu.Lock()
+	a.counts[grade]--
+	if a.counts[grade] == 0 {
+		delete(a.counts, grade)
+	}
+	a.total--
+	a.mu.Unlock()
+}
+
+func (a *gradeDistribution) Check(_ validator.FileStats) error {
+	if a.total == 0 {
+		return nil
+	}
+	grades := make([]string, 0, len(a.baseline)+len(a.counts))
+	for grade := range a.baseline {
+		grades = append(grades, grade)
+	}
+	for grade := range a.counts {
+		if _, ok := a.baseline[grade]; !ok {
+			grades = append(grades, grade)
+		}
+	}
+	slices.Sort(grades)
+	for _, grade := range grades {
+		share := float64(a.counts[grade]) / float64(a.total)
+		if math.Abs(share-a.baseline[grade]) > a.maxDrift {
+			return fmt.Errorf("%w: grade %s is %.4f of loans, baseline %.4f", ErrGradeDrift, grade, share, a.baseline[grade])
+		}
+	}
+	return nil
+}
+
+// Aggregate Rule: Row Count
+// The file contains exactly the expected number of data rows, valid or not.
+type rowCount struct {
+	expected int64
+}
+
+func (rowCount) Add(*validator.RowValidatorContext, []string, map[string]string) {}
+
+func (rowCount) Remove(*validator.RowValidatorContext, []string, map[string]string) {}
+
+func (a rowCount) Check(stats validator.FileStats) error {
+	if stats.Rows != a.expected {
+		return fmt.Errorf("%w: read %d, expected %d", ErrRowCountMismatch, stats.Rows, a.expected)
+	}
+	return nil
+}
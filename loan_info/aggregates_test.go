@@ -0,0 +1,159 @@
+package loan_info
+
+import (
+	"errors"
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+func aggregateCols(issueD, funded, grade string) []string {
+	return createColumnsWithValues(map[int]string{
+		colIssueD:        issueD,
+		colFundingAmount: funded,
+		colGrade:         grade,
+	})
+}
+
+// runAggregates adds rows to the rules configured by agg and returns the first violation.
+func runAggregates(t *testing.T, agg config.AggregateConfig, rows [][]string, stats validator.FileStats) error {
+	t.Helper()
+	conf := &config.ParserConfig{Aggregates: agg}
+	ctx := &validator.RowValidatorContext{Config: conf, GetMap: mockGetMap}
+	aggregateRules := AggregateRules(conf)
+	accumulate := validator.Accumulate(aggregateRules)
+	for _, cols := range rows {
+		accumulate(ctx, cols, map[string]string{})
+	}
+	errs := validator.CheckAggregates(aggregateRules, stats)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+func TestAggregateRules_Unconfigured(t *testing.T) {
+	if rules := AggregateRules(&config.ParserConfig{}); len(rules) != 0 {
+		t.Errorf("expected no aggregate rules, got %d", len(rules))
+	}
+}
+
+func TestMonthlyFunded(t *testing.T) {
+	rows := [][]string{
+		aggregateCols("Dec-2015", "3600.0", "C"),
+		aggregateCols("Dec-2015", "24700.0", "C"),
+		aggregateCols("Jan-2016", "20000.0", "B"),
+		aggregateCols("sometime", "99999.0", "B"),
+	}
+	testCases := []struct {
+		name    string
+		agg     config.AggregateConfig
+		wantErr error
+	}{
+		{name: "within bounds", agg: config.AggregateConfig{MinMonthlyFunded: 10000, MaxMonthlyFunded: 30000}},
+		{name: "month above max", agg: config.AggregateConfig{MaxMonthlyFunded: 25000}, wantErr: ErrMonthlyFundedAboveMax},
+		{name: "month below min", agg: config.AggregateConfig{MinMonthlyFunded: 25000}, wantErr: ErrMonthlyFundedBelowMin},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := runAggregates(t, tc.agg, rows, validator.FileStats{Rows: 4, ValidRows: 4})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMonthlyFunded_ErrorMessage(t *testing.T) {
+	rows := [][]string{aggregateCols("Dec-2015", "3600.0", "C"), aggregateCols("Dec-2015", "24700.0", "C")}
+	err := runAggregates(t, config.AggregateConfig{MaxMonthlyFunded: 25000}, rows, validator.FileStats{})
+	want := "monthly_funded_total: total funded amount for the month is above the maximum: 2015-12 funded 28300"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected '%s', got '%v'", want, err)
+	}
+}
+
+func TestGradeDistribution(t *testing.T) {
+	rows := [][]string{
+		aggregateCols("Dec-2015", "1000", "A"),
+		aggregateCols("Dec-2015", "1000", "b"),
+		aggregateCols("Dec-2015", "1000", "B"),
+		aggregateCols("Dec-2015", "1000", "C"),
+	}
+	testCases := []struct {
+		name    string
+		agg     config.AggregateConfig
+		wantErr error
+	}{
+		{
+			name: "matches baseline",
+			agg:  config.AggregateConfig{GradeBaseline: map[string]float64{"A": 0.25, "B": 0.5, "C": 0.25}, MaxGradeDrift: 0.01},
+		},
+		{
+			name: "within drift",
+			agg:  config.AggregateConfig{GradeBaseline: map[string]float64{"A": 0.3, "B": 0.45, "C": 0.25}, MaxGradeDrift: 0.05},
+		},
+		{
+			name:    "drifted grade",
+			agg:     config.AggregateConfig{GradeBaseline: map[string]float64{"A": 0.5, "B": 0.25, "C": 0.25}, MaxGradeDrift: 0.05},
+			wantErr: ErrGradeDrift,
+		},
+		{
+			name:    "grade missing from baseline",
+			agg:     config.AggregateConfig{GradeBaseline: map[string]float64{"A": 0.25, "B": 0.75}, MaxGradeDrift: 0.2},
+			wantErr: ErrGradeDrift,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := runAggregates(t, tc.agg, rows, validator.FileStats{Rows: 4, ValidRows: 4})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAggregates_Removing(t *testing.T) {
+	conf := &config.ParserConfig{Aggregates: config.AggregateConfig{
+		MaxMonthlyFunded: 25000,
+		GradeBaseline:    map[string]float64{"A": 0.5, "B": 0.5},
+		MaxGradeDrift:    0.01,
+	}}
+	ctx := &validator.RowValidatorContext{Config: conf, GetMap: mockGetMap}
+	aggregateRules := AggregateRules(conf)
+	accumulate := validator.Accumulate(aggregateRules)
+	for _, cols := range [][]string{
+		aggregateCols("Dec-2015", "20000", "A"),
+		aggregateCols("Dec-2015", "10000", "B"),
+		aggregateCols("Dec-2015", "1000", "C"),
+	} {
+		accumulate(ctx, cols, map[string]string{})
+	}
+	if errs := validator.CheckAggregates(aggregateRules, validator.FileStats{}); len(errs) != 2 {
+		t.Fatalf("expected both rules to fail before the row is removed, got %v", errs)
+	}
+
+	validator.Accumulate(validator.Removing(aggregateRules))(ctx, aggregateCols("Dec-2015", "1000", "C"), map[string]string{})
+	if errs := validator.CheckAggregates(aggregateRules, validator.FileStats{}); len(errs) != 1 || !errors.Is(errs[0], ErrMonthlyFundedAboveMax) {
+		t.Errorf("expected only the monthly total to fail once grade C is removed, got %v", errs)
+	}
+	validator.Accumulate(validator.Removing(aggregateRules))(ctx, aggregateCols("Dec-2015", "10000", "B"), map[string]string{})
+	accumulate(ctx, aggregateCols("Dec-2015", "4000", "B"), map[string]string{})
+	if errs := validator.CheckAggregates(aggregateRules, validator.FileStats{}); len(errs) != 0 {
+		t.Errorf("expected the replaced row to bring the total within bounds, got %v", errs)
+	}
+}
+
+func TestRowCount(t *testing.T) {
+	agg := config.AggregateConfig{ExpectedRows: 10}
+	if err := runAggregates(t, agg, nil, validator.FileStats{Rows: 10, ValidRows: 7}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := runAggregates(t, agg, nil, validator.FileStats{Rows: 9, ValidRows: 9})
+	if !errors.Is(err, ErrRowCountMismatch) {
+		t.Errorf("expected row count error, got %v", err)
+	}
+}
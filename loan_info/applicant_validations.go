@@ -3,7 +3,6 @@ package loan_info
 import (
 	"go-file-parsing/utils"
 	"go-file-parsing/validator"
-	"time"
 )
 
 // Column index constants for CSV fields
@@ -12,6 +11,7 @@ const (
 	colEmpLength          = 11
 	colAnnualInc          = 13
 	colVerificationStatus = 14
+	colIssueD             = 15
 	colDTI                = 24
 	colEarliestCrLine     = 26
 	colFICORangeLow       = 27
@@ -19,11 +19,15 @@ const (
 	colOpenAcc            = 32
 	colPubRec             = 33
 	colTotalAcc           = 36
+	colLastPymntD         = 47
 	colPubRecBankruptcies = 109
 	colTaxLiens           = 110
 )
 
-var tenYearsAgo = time.Now().AddDate(-10, 0, 0)
+var (
+	maxDTI          = utils.NewDecimal(20)
+	minAnnualIncome = utils.NewDecimal(30000)
+)
 
 func validateFormattedInt(s *string, parseError error, rangeCheck func(int) error) error {
 	var err error
@@ -35,6 +39,17 @@ func validateFormattedInt(s *string, parseError error, rangeCheck func(int) erro
 
 }
 
+// validateDecimal parses s as a fixed-point decimal in the column's number format and runs rangeCheck on it.
+// On success s is replaced with the canonical form of the value, e.g. "18.70%" becomes "18.7".
+func validateDecimal(vCtx *validator.RowValidatorContext, column string, s *string, parseError error, rangeCheck func(utils.Decimal) error) error {
+	d, err := parseDecimalColumn(vCtx, column, *s)
+	if err != nil {
+		return parseError
+	}
+	*s = d.String()
+	return rangeCheck(d)
+}
+
 // Rule 5: Has Employment Info
 // Non-empty emp_title and emp_length is not null.
 func hasEmploymentInfo(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
@@ -70,8 +85,8 @@ func hasLowDTI(vCtx *validator.RowValidatorContext, cols []string) (map[string]s
 	result := vCtx.GetMap()
 	var err error
 	dtiStr := utils.TrimIfNeeded(cols[colDTI])
-	err = validateFormattedInt(&dtiStr, ErrDTINotNumber, func(i int) error {
-		if i > 20 {
+	err = validateDecimal(vCtx, "dti", &dtiStr, ErrDTINotNumber, func(d utils.Decimal) error {
+		if d.Cmp(maxDTI) > 0 {
 			return ErrDTITooHigh
 		}
 		result["dti"] = dtiStr
@@ -94,28 +109,30 @@ func hasLowDTI(vCtx *validator.RowValidatorContext, cols []string) (map[string]s
 }
 
 // Rule 7: Established Credit History
-// earliest_cr_line not null and is > 10 years ago.
+// earliest_cr_line not null and is > 10 years before the as-of date.
 func hasEstablishedCreditHistory(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
 	earliestCRLine := utils.TrimIfNeeded(cols[colEarliestCrLine])
 
 	if earliestCRLine == "" {
 		return nil, ErrEarliestCrLineEmpty
 	}
-	result := vCtx.GetMap()
-	var err error
-	// Parse the date in format YYYY-MM
-	workTime, err := time.Parse("2006-01", earliestCRLine)
+	// Parse the date with the layouts configured for earliest_cr_line (e.g. Aug-2003)
+	workTime, isoDate, err := parseDateColumn(vCtx, "earliest_cr_line", earliestCRLine)
 	if err != nil {
-		validator.PutMap(result)
 		return nil, ErrEarliestCrLineFormat
 	}
 
-	// Check if the date is more than 10 years ago
-	if workTime.After(tenYearsAgo) {
-		validator.PutMap(result)
+	asOf, err := asOfDate(vCtx, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the date is more than 10 years before the as-of date
+	if workTime.After(asOf.AddDate(-creditHistoryYears, 0, 0)) {
 		return nil, ErrEarliestCrLineTooRecent
 	}
-	result["earliestCrLine"] = earliestCRLine
+	result := vCtx.GetMap()
+	result["earliestCrLine"] = isoDate
 
 	return result, nil
 }
@@ -247,8 +264,8 @@ func isVerifiedWithIncome(vCtx *validator.RowValidatorContext, cols []string) (m
 
 	// Get a map from the pool
 	result := vCtx.GetMap()
-	err := validateFormattedInt(&annualIncStr, ErrAnnualIncNotNumber, func(i int) error {
-		if i <= 30000 {
+	err := validateDecimal(vCtx, "annual_inc", &annualIncStr, ErrAnnualIncNotNumber, func(d utils.Decimal) error {
+		if d.Cmp(minAnnualIncome) <= 0 {
 			return ErrAnnualIncTooLow30K
 		}
 		return nil
@@ -1,6 +1,7 @@
 package loan_info
 
 import (
+	"cmp"
 	"go-file-parsing/config"
 	"go-file-parsing/validator"
 	"testing"
@@ -11,6 +12,15 @@ func mockGetMap() map[string]string {
 	return make(map[string]string)
 }
 
+// checkedConfig returns conf with its AsOfDate resolved, as LoadParserConfig and the validator pool do.
+func checkedConfig(t *testing.T, conf config.ParserConfig) *config.ParserConfig {
+	t.Helper()
+	if err := conf.CheckAsOfDate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &conf
+}
+
 func TestHasEmploymentInfo(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -87,12 +97,31 @@ func TestHasLowDTIAndHomeOwnership(t *testing.T) {
 		cols    []string
 		wantErr bool
 		errMsg  string
+		// cached is the canonical dti, when it differs from cols[24]
+		cached string
 	}{
 		{
 			name:    "valid DTI and home ownership",
 			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "MORTGAGE", "50000", "", "", "", "", "", "", "", "", "", "", "15"},
 			wantErr: false,
 		},
+		{
+			name:    "valid fractional DTI",
+			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "MORTGAGE", "50000", "", "", "", "", "", "", "", "", "", "", "18.7"},
+			wantErr: false,
+		},
+		{
+			name:    "DTI is cached in canonical form",
+			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "MORTGAGE", "50000", "", "", "", "", "", "", "", "", "", "", "18.70"},
+			wantErr: false,
+			cached:  "18.7",
+		},
+		{
+			name:    "DTI just above limit",
+			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "MORTGAGE", "50000", "", "", "", "", "", "", "", "", "", "", "20.01"},
+			wantErr: true,
+			errMsg:  "DTI is not less than 20",
+		},
 		{
 			name:    "DTI not a number",
 			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "MORTGAGE", "50000", "", "", "", "", "", "", "", "", "", "", "abc"},
@@ -141,8 +170,8 @@ func TestHasLowDTIAndHomeOwnership(t *testing.T) {
 			}
 
 			// Verify the returned map contains the expected values
-			if result["dti"] != tc.cols[24] {
-				t.Errorf("expected dti '%s', got '%s'", tc.cols[24], result["dti"])
+			if cached := cmp.Or(tc.cached, tc.cols[24]); result["dti"] != cached {
+				t.Errorf("expected dti '%s', got '%s'", cached, result["dti"])
 			}
 			if result["homeOwnership"] != tc.cols[12] {
 				t.Errorf("expected homeOwnership '%s', got '%s'", tc.cols[12], result["homeOwnership"])
@@ -178,7 +207,7 @@ func TestHasEstablishedCreditHistory(t *testing.T) {
 			name:    "invalid date format",
 			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "invalid-date"},
 			wantErr: true,
-			errMsg:  "earliest credit line is not in valid format (YYYY-MM)",
+			errMsg:  "earliest credit line does not match a configured date layout",
 		},
 		{
 			name:    "credit history too recent",
@@ -223,6 +252,88 @@ func TestHasEstablishedCreditHistory(t *testing.T) {
 	}
 }
 
+func TestHasEstablishedCreditHistory_AsOfDate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   config.ParserConfig
+		cols     []string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "dataset format is cached as ISO",
+			config:   config.ParserConfig{AsOfDate: "2015-12-01"},
+			cols:     createTestCols(26, "Aug-2003"),
+			expected: "2003-08",
+		},
+		{
+			name:   "too recent for fixed as-of date",
+			config: config.ParserConfig{AsOfDate: "2010-01-01"},
+			cols:   createTestCols(26, "Aug-2003"),
+			errMsg: "earliest credit line is not more than 10 years ago",
+		},
+		{
+			name:     "old enough relative to issue date",
+			config:   config.ParserConfig{AsOfDate: config.AsOfIssueDate},
+			cols:     createTestCols(15, "Dec-2015", 26, "Aug-2003"),
+			expected: "2003-08",
+		},
+		{
+			name:   "too recent relative to issue date",
+			config: config.ParserConfig{AsOfDate: config.AsOfIssueDate},
+			cols:   createTestCols(15, "Dec-2012", 26, "Aug-2003"),
+			errMsg: "earliest credit line is not more than 10 years ago",
+		},
+		{
+			name:   "unparseable issue date",
+			config: config.ParserConfig{AsOfDate: config.AsOfIssueDate},
+			cols:   createTestCols(15, "", 26, "Aug-2003"),
+			errMsg: "issue date does not match a configured date layout",
+		},
+		{
+			name: "configured layouts replace the defaults",
+			config: config.ParserConfig{
+				AsOfDate:    "2015-12-01",
+				DateLayouts: map[string][]string{"earliest_cr_line": {"01/02/2006"}},
+			},
+			cols:     createTestCols(26, "08/15/2003"),
+			expected: "2003-08-15",
+		},
+		{
+			name: "default layout not accepted once overridden",
+			config: config.ParserConfig{
+				AsOfDate:    "2015-12-01",
+				DateLayouts: map[string][]string{"earliest_cr_line": {"01/02/2006"}},
+			},
+			cols:   createTestCols(26, "Aug-2003"),
+			errMsg: "earliest credit line does not match a configured date layout",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: checkedConfig(t, tc.config),
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasEstablishedCreditHistory(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["earliestCrLine"] != tc.expected {
+				t.Errorf("expected earliestCrLine '%s', got '%s'", tc.expected, result["earliestCrLine"])
+			}
+		})
+	}
+}
+
 func TestHasHealthyFICOScore(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -472,6 +583,8 @@ func TestIsVerifiedWithIncome(t *testing.T) {
 		cols    []string
 		wantErr bool
 		errMsg  string
+		// annualInc is the expected cached value when it differs from the raw column
+		annualInc string
 	}{
 		{
 			name:    "valid - Source Verified with sufficient income",
@@ -483,6 +596,17 @@ func TestIsVerifiedWithIncome(t *testing.T) {
 			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "", "40000", "Verified"},
 			wantErr: false,
 		},
+		{
+			name:    "valid - income with cents",
+			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "", "30000.5", "Verified"},
+			wantErr: false,
+		},
+		{
+			name:      "valid - income with grouping",
+			cols:      []string{"", "", "", "", "", "", "", "", "", "", "", "", "", "45,000", "Verified"},
+			wantErr:   false,
+			annualInc: "45000",
+		},
 		{
 			name:    "invalid - Not Verified",
 			cols:    []string{"", "", "", "", "", "", "", "", "", "", "", "", "", "40000", "Not Verified"},
@@ -540,13 +664,30 @@ func TestIsVerifiedWithIncome(t *testing.T) {
 			if result["verificationStatus"] != tc.cols[14] {
 				t.Errorf("expected verificationStatus '%s', got '%s'", tc.cols[14], result["verificationStatus"])
 			}
-			if result["annualInc"] != tc.cols[13] {
-				t.Errorf("expected annualInc '%s', got '%s'", tc.cols[13], result["annualInc"])
+			expectedInc := tc.cols[13]
+			if tc.annualInc != "" {
+				expectedInc = tc.annualInc
+			}
+			if result["annualInc"] != expectedInc {
+				t.Errorf("expected annualInc '%s', got '%s'", expectedInc, result["annualInc"])
 			}
 		})
 	}
 }
 
+func TestIsVerifiedWithIncome_QuotedGroupedIncome(t *testing.T) {
+	// A grouped income is quoted in a comma-delimited file, and must reach the rule as one column
+	line := `1,,,,,,,,,,,,,"45,000",Verified`
+	cacheChan := make(chan validator.CacheData, 1)
+	v := validator.New(&config.ParserConfig{Delimiter: ","}, cacheChan, []validator.ColValidator{isVerifiedWithIncome})
+	if _, err := v.Validate(line); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := (<-cacheChan).Data["annualInc"]; got != "45000" {
+		t.Errorf("expected annualInc '45000', got '%s'", got)
+	}
+}
+
 // Helper function to create test columns with specific values at specific indices
 func createTestCols(indices ...interface{}) []string {
 	// Find the maximum index to determine the slice size
@@ -0,0 +1,130 @@
+package loan_info
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"math"
+	"strings"
+)
+
+const colInstallment = 7
+
+// installmentTolerance is the relative difference allowed between the reported installment
+// and the one computed from the amortization formula, to absorb rounding in the export.
+const installmentTolerance = 0.01
+
+// ficoRangeWidth is the difference between fico_range_high and fico_range_low in every bureau band.
+const ficoRangeWidth = 4
+
+// rateBand is the inclusive interest rate range, in percent, expected for a grade.
+type rateBand struct {
+	min, max utils.Decimal
+}
+
+// gradeRateBands are deliberately wide: they span every pricing table used between 2007 and 2018.
+var gradeRateBands = map[byte]rateBand{
+	'A': {min: utils.NewDecimal(5), max: utils.NewDecimal(12)},
+	'B': {min: utils.NewDecimal(6), max: utils.NewDecimal(16)},
+	'C': {min: utils.NewDecimal(8), max: utils.NewDecimal(21)},
+	'D': {min: utils.NewDecimal(10), max: utils.NewDecimal(26)},
+	'E': {min: utils.NewDecimal(12), max: utils.NewDecimal(29)},
+	'F': {min: utils.NewDecimal(14), max: utils.NewDecimal(31)},
+	'G': {min: utils.NewDecimal(16), max: utils.NewDecimal(31)},
+}
+
+// amortizedInstallment returns the monthly payment for a fully amortizing loan.
+// annualRate is in percent, e.g. 13.99.
+func amortizedInstallment(principal, annualRate float64, months int) float64 {
+	if annualRate == 0 {
+		return principal / float64(months)
+	}
+	r := annualRate / 100 / 12
+	return principal * r / (1 - math.Pow(1+r, -float64(months)))
+}
+
+// Rule 16: Installment Matches Amortization
+// installment is within 1% of the payment computed from loan_amnt, int_rate and term.
+func hasConsistentInstallment(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	installment, err := parseDecimalColumn(vCtx, "installment", cols[colInstallment])
+	if err != nil {
+		return nil, ErrInstallmentNotNumber
+	}
+	loanAmount, err := parseDecimalColumn(vCtx, "loan_amnt", cols[colLoanAmount])
+	if err != nil {
+		return nil, ErrLoanAmountNotNumber
+	}
+	rate, err := parseDecimalColumn(vCtx, "int_rate", cols[colInterestRate])
+	if err != nil {
+		return nil, ErrInterestRateNotNumber
+	}
+	term, err := parseTermMonths(cols[colTerm])
+	if err != nil || term <= 0 {
+		return nil, ErrTermNotNumber
+	}
+
+	expected := amortizedInstallment(loanAmount.Float64(), rate.Float64(), term)
+	if math.Abs(installment.Float64()-expected) > expected*installmentTolerance {
+		return nil, ErrInstallmentInconsistent
+	}
+
+	result := vCtx.GetMap()
+	result["installment"] = installment.String()
+	return result, nil
+}
+
+// Rule 17: Grade Matches Interest Rate
+// int_rate falls inside the band expected for the grade letter of sub_grade.
+func hasConsistentGradeRate(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	subgrade := strings.ToUpper(utils.TrimIfNeeded(cols[colSubgrade]))
+	if subgrade == "" {
+		return nil, ErrSubgradeInvalid
+	}
+	band, ok := gradeRateBands[subgrade[0]]
+	if !ok {
+		return nil, ErrSubgradeInvalid
+	}
+	rate, err := parseDecimalColumn(vCtx, "int_rate", cols[colInterestRate])
+	if err != nil {
+		return nil, ErrInterestRateNotNumber
+	}
+	if rate.Cmp(band.min) < 0 || rate.Cmp(band.max) > 0 {
+		return nil, ErrGradeRateInconsistent
+	}
+	return nil, nil
+}
+
+// Rule 18: Funded Amount Within Loan Amount
+// funded_amnt <= loan_amnt.
+func hasFundedWithinLoanAmount(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	loanAmount, err := parseDecimalColumn(vCtx, "loan_amnt", cols[colLoanAmount])
+	if err != nil {
+		return nil, ErrLoanAmountNotNumber
+	}
+	fundingAmount, err := parseDecimalColumn(vCtx, "funded_amnt", cols[colFundingAmount])
+	if err != nil {
+		return nil, ErrFundingAmountNotNumber
+	}
+	if fundingAmount.Cmp(loanAmount) > 0 {
+		return nil, ErrFundedExceedsLoanAmount
+	}
+	return nil, nil
+}
+
+// Rule 19: FICO Range Width
+// fico_range_high - fico_range_low == 4.
+func hasConsistentFICORange(_ *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	ficoLowStr := utils.TrimIfNeeded(cols[colFICORangeLow])
+	ficoLow, err := utils.FormattedStringToInt(&ficoLowStr)
+	if err != nil {
+		return nil, ErrFICORangeLowNotNumber
+	}
+	ficoHighStr := utils.TrimIfNeeded(cols[colFICORangeHigh])
+	ficoHigh, err := utils.FormattedStringToInt(&ficoHighStr)
+	if err != nil {
+		return nil, ErrFICORangeHighNotNumber
+	}
+	if ficoHigh-ficoLow != ficoRangeWidth {
+		return nil, ErrFICORangeWidthInvalid
+	}
+	return nil, nil
+}
@@ -0,0 +1,245 @@
+package loan_info
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"math"
+	"testing"
+)
+
+func TestAmortizedInstallment(t *testing.T) {
+	// First loan in sample.csv: 3600 at 13.99% over 36 months pays 123.03
+	got := amortizedInstallment(3600, 13.99, 36)
+	if math.Abs(got-123.03) > 0.01 {
+		t.Errorf("expected 123.03, got %.4f", got)
+	}
+	if got := amortizedInstallment(1200, 0, 12); got != 100 {
+		t.Errorf("expected 100 for a zero-rate loan, got %.4f", got)
+	}
+}
+
+func TestHasConsistentInstallment(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "dataset row",
+			cols: createTestCols(2, "3600.0", 5, " 36 months", 6, "13.99", 7, "123.03"),
+		},
+		{
+			name: "second dataset row",
+			cols: createTestCols(2, "24700.0", 5, " 36 months", 6, "11.99", 7, "820.28"),
+		},
+		{
+			name: "percent-suffixed rate",
+			cols: createTestCols(2, "3600.0", 5, " 36 months", 6, " 13.99%", 7, "123.03"),
+		},
+		{
+			name:   "installment off by more than tolerance",
+			cols:   createTestCols(2, "3600.0", 5, " 36 months", 6, "13.99", 7, "130.00"),
+			errMsg: "installment does not match loan amount, interest rate and term",
+		},
+		{
+			name:   "installment for the wrong term",
+			cols:   createTestCols(2, "3600.0", 5, " 60 months", 6, "13.99", 7, "123.03"),
+			errMsg: "installment does not match loan amount, interest rate and term",
+		},
+		{
+			name:   "installment not a number",
+			cols:   createTestCols(2, "3600.0", 5, " 36 months", 6, "13.99", 7, ""),
+			errMsg: "installment is not a number",
+		},
+		{
+			name:   "term not a number",
+			cols:   createTestCols(2, "3600.0", 5, "three years", 6, "13.99", 7, "123.03"),
+			errMsg: "term is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasConsistentInstallment(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["installment"] != tc.cols[7] {
+				t.Errorf("expected installment '%s', got '%s'", tc.cols[7], result["installment"])
+			}
+		})
+	}
+}
+
+func TestHasConsistentGradeRate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "C grade rate",
+			cols: createTestCols(6, "13.99", 9, "C4"),
+		},
+		{
+			name: "lower-case subgrade",
+			cols: createTestCols(6, "7.5", 9, "a2"),
+		},
+		{
+			name:   "A grade priced like a D",
+			cols:   createTestCols(6, "22.5", 9, "A1"),
+			errMsg: "interest rate is outside the band for the subgrade",
+		},
+		{
+			name:   "G grade priced like an A",
+			cols:   createTestCols(6, "6.0", 9, "G5"),
+			errMsg: "interest rate is outside the band for the subgrade",
+		},
+		{
+			name:   "unknown subgrade",
+			cols:   createTestCols(6, "13.99", 9, "H1"),
+			errMsg: "subgrade must be the grade letter followed by a number from 1 to 5",
+		},
+		{
+			name:   "rate not a number",
+			cols:   createTestCols(6, "abc", 9, "C4"),
+			errMsg: "interest rate is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			_, err := hasConsistentGradeRate(ctx, tc.cols)
+			if tc.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.errMsg {
+				t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestHasFundedWithinLoanAmount(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "fully funded",
+			cols: createTestCols(2, "3600.0", 3, "3600.0"),
+		},
+		{
+			name: "partially funded",
+			cols: createTestCols(2, "3600.0", 3, "3575.00"),
+		},
+		{
+			name:   "over funded",
+			cols:   createTestCols(2, "3600.0", 3, "3600.01"),
+			errMsg: "funding amount is greater than loan amount",
+		},
+		{
+			name:   "loan amount not a number",
+			cols:   createTestCols(2, "", 3, "3600.0"),
+			errMsg: "loan amount is not a number",
+		},
+		{
+			name:   "funding amount not a number",
+			cols:   createTestCols(2, "3600.0", 3, "abc"),
+			errMsg: "funding amount is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			_, err := hasFundedWithinLoanAmount(ctx, tc.cols)
+			if tc.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.errMsg {
+				t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestHasConsistentFICORange(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "dataset band",
+			cols: createTestCols(27, "675.0", 28, "679.0"),
+		},
+		{
+			name:   "band too wide",
+			cols:   createTestCols(27, "675", 28, "684"),
+			errMsg: "FICO range high is not 4 points above FICO range low",
+		},
+		{
+			name:   "band reversed",
+			cols:   createTestCols(27, "679", 28, "675"),
+			errMsg: "FICO range high is not 4 points above FICO range low",
+		},
+		{
+			name:   "low not a number",
+			cols:   createTestCols(27, "", 28, "679"),
+			errMsg: "FICO range low is not a number",
+		},
+		{
+			name:   "high not a number",
+			cols:   createTestCols(27, "675", 28, "abc"),
+			errMsg: "FICO range high is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			_, err := hasConsistentFICORange(ctx, tc.cols)
+			if tc.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.errMsg {
+				t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package loan_info
+
+import (
+	"go-file-parsing/loan_info/formats"
+	"go-file-parsing/validator"
+	"time"
+)
+
+// defaultDateLayouts lists the layouts accepted for each date column.
+// Entries in ParserConfig.DateLayouts replace these per column.
+var defaultDateLayouts = map[string][]string{
+	"issue_d":                  formats.MonthLayouts,
+	"earliest_cr_line":         formats.MonthLayouts,
+	"last_pymnt_d":             formats.MonthLayouts,
+	"sec_app_earliest_cr_line": formats.MonthLayouts,
+}
+
+// creditHistoryYears is how long before the as-of date the earliest credit line must be.
+const creditHistoryYears = 10
+
+// parseDateColumn parses a date column with its layouts and returns the value and its ISO form.
+func parseDateColumn(vCtx *validator.RowValidatorContext, column, value string) (time.Time, string, error) {
+	return formats.ParseDate(vCtx, column, defaultDateLayouts[column], value)
+}
+
+// asOfDate returns the reference date relative rules are measured from for this row.
+func asOfDate(vCtx *validator.RowValidatorContext, cols []string) (time.Time, error) {
+	if vCtx.Config == nil {
+		return time.Now(), nil
+	}
+	asOf, fromIssueDate := vCtx.Config.AsOf()
+	if fromIssueDate {
+		issueDate, _, err := parseDateColumn(vCtx, "issue_d", cols[colIssueD])
+		if err != nil {
+			return time.Time{}, ErrIssueDateFormat
+		}
+		return issueDate, nil
+	}
+	if asOf.IsZero() {
+		return time.Now(), nil
+	}
+	return asOf, nil
+}
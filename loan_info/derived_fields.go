@@ -0,0 +1,71 @@
+package loan_info
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"strconv"
+)
+
+// Derived fields are computed from the raw columns rather than the cached record, so they do not depend on
+// which rules are enabled. A field is skipped when its inputs are missing or do not parse.
+
+// formatRatio formats a derived ratio with four decimal places, matching utils.DecimalScale.
+func formatRatio(f float64) string {
+	return strconv.FormatFloat(f, 'f', utils.DecimalScale, 64)
+}
+
+// deriveLoanToIncome adds loanToIncome: loan_amnt / annual_inc.
+func deriveLoanToIncome(vCtx *validator.RowValidatorContext, cols []string, record map[string]string) {
+	loanAmount, err := parseDecimalColumn(vCtx, "loan_amnt", cols[colLoanAmount])
+	if err != nil {
+		return
+	}
+	annualInc, err := parseDecimalColumn(vCtx, "annual_inc", cols[colAnnualInc])
+	if err != nil || annualInc.Sign() <= 0 {
+		return
+	}
+	record["loanToIncome"] = formatRatio(loanAmount.Float64() / annualInc.Float64())
+}
+
+// deriveCreditHistoryMonths adds creditHistoryMonths: whole months from earliest_cr_line to the as-of date.
+func deriveCreditHistoryMonths(vCtx *validator.RowValidatorContext, cols []string, record map[string]string) {
+	earliest, _, err := parseDateColumn(vCtx, "earliest_cr_line", cols[colEarliestCrLine])
+	if err != nil {
+		return
+	}
+	asOf, err := asOfDate(vCtx, cols)
+	if err != nil {
+		return
+	}
+	months := (asOf.Year()-earliest.Year())*12 + int(asOf.Month()) - int(earliest.Month())
+	if asOf.Day() < earliest.Day() {
+		months--
+	}
+	record["creditHistoryMonths"] = strconv.Itoa(months)
+}
+
+// deriveFICOMidpoint adds ficoMidpoint: the middle of fico_range_low and fico_range_high.
+func deriveFICOMidpoint(_ *validator.RowValidatorContext, cols []string, record map[string]string) {
+	low, err := utils.ParseDecimal(utils.TrimIfNeeded(cols[colFICORangeLow]))
+	if err != nil {
+		return
+	}
+	high, err := utils.ParseDecimal(utils.TrimIfNeeded(cols[colFICORangeHigh]))
+	if err != nil {
+		return
+	}
+	record["ficoMidpoint"] = ((low + high) / 2).String()
+}
+
+// deriveInstallmentBurden adds installmentToIncome: twelve monthly installments / annual_inc.
+func deriveInstallmentBurden(vCtx *validator.RowValidatorContext, cols []string, record map[string]string) {
+	installment, err := parseDecimalColumn(vCtx, "installment", cols[colInstallment])
+	if err != nil {
+		return
+	}
+	annualInc, err := parseDecimalColumn(vCtx, "annual_inc", cols[colAnnualInc])
+	if err != nil || annualInc.Sign() <= 0 {
+		return
+	}
+	record["installmentToIncome"] = formatRatio(installment.Float64() * 12 / annualInc.Float64())
+}
@@ -0,0 +1,78 @@
+package loan_info
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+func derivedCols(overrides map[int]string) []string {
+	values := map[int]string{
+		colLoanAmount:     "3600.0",
+		colInstallment:    "123.03",
+		colAnnualInc:      "55000.0",
+		colIssueD:         "Dec-2015",
+		colEarliestCrLine: "Aug-2003",
+		colFICORangeLow:   "675.0",
+		colFICORangeHigh:  "679.0",
+	}
+	for idx, val := range overrides {
+		values[idx] = val
+	}
+	return createColumnsWithValues(values)
+}
+
+func TestDerivations(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cols    []string
+		want    map[string]string
+		missing []string
+	}{
+		{
+			name: "dataset row",
+			cols: derivedCols(nil),
+			want: map[string]string{
+				"loanToIncome":        "0.0655",
+				"creditHistoryMonths": "148",
+				"ficoMidpoint":        "677",
+				"installmentToIncome": "0.0268",
+			},
+		},
+		{
+			name:    "zero income",
+			cols:    derivedCols(map[int]string{colAnnualInc: "0"}),
+			want:    map[string]string{"creditHistoryMonths": "148", "ficoMidpoint": "677"},
+			missing: []string{"loanToIncome", "installmentToIncome"},
+		},
+		{
+			name:    "unparseable dates and scores",
+			cols:    derivedCols(map[int]string{colEarliestCrLine: "sometime", colFICORangeHigh: ""}),
+			want:    map[string]string{"loanToIncome": "0.0655"},
+			missing: []string{"creditHistoryMonths", "ficoMidpoint"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{AsOfDate: config.AsOfIssueDate},
+				GetMap: mockGetMap,
+			}
+			record := make(map[string]string)
+			for _, derive := range derivations {
+				derive(ctx, tc.cols, record)
+			}
+			for key, want := range tc.want {
+				if record[key] != want {
+					t.Errorf("expected %s '%s', got '%s'", key, want, record[key])
+				}
+			}
+			for _, key := range tc.missing {
+				if _, ok := record[key]; ok {
+					t.Errorf("expected %s to be skipped, got '%s'", key, record[key])
+				}
+			}
+		})
+	}
+}
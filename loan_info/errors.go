@@ -54,10 +54,15 @@ var (
 // Credit history validation errors
 var (
 	ErrEarliestCrLineEmpty     = errors.New("earliest credit line is empty")
-	ErrEarliestCrLineFormat    = errors.New("earliest credit line is not in valid format (YYYY-MM)")
+	ErrEarliestCrLineFormat    = errors.New("earliest credit line does not match a configured date layout")
 	ErrEarliestCrLineTooRecent = errors.New("earliest credit line is not more than 10 years ago")
 )
 
+// As-of date errors
+var (
+	ErrIssueDateFormat = errors.New("issue date does not match a configured date layout")
+)
+
 // FICO score validation errors
 var (
 	ErrFICORangeLowNotNumber  = errors.New("FICO range low is not a number")
@@ -88,3 +93,52 @@ var (
 var (
 	ErrVerificationStatusInvalid = errors.New("verification status is not Source Verified or Verified")
 )
+
+// Joint application validation errors
+var (
+	ErrAnnualIncJointNotNumber       = errors.New("joint annual income is not a number")
+	ErrAnnualIncJointTooLow          = errors.New("joint annual income is not greater than 30,000")
+	ErrDTIJointNotNumber             = errors.New("joint DTI is not a number")
+	ErrDTIJointTooHigh               = errors.New("joint DTI is not less than 20")
+	ErrSecAppFICORangeLowNotNumber   = errors.New("secondary applicant FICO range low is not a number")
+	ErrSecAppFICORangeHighNotNumber  = errors.New("secondary applicant FICO range high is not a number")
+	ErrSecAppFICORangeLowTooLow      = errors.New("secondary applicant FICO range low is less than 660")
+	ErrSecAppFICORangeHighTooHigh    = errors.New("secondary applicant FICO range high is greater than 850")
+	ErrSecAppEarliestCrLineEmpty     = errors.New("secondary applicant earliest credit line is empty")
+	ErrSecAppEarliestCrLineFormat    = errors.New("secondary applicant earliest credit line does not match a configured date layout")
+	ErrSecAppEarliestCrLineTooRecent = errors.New("secondary applicant earliest credit line is not more than 10 years ago")
+)
+
+// Cross-field consistency errors
+var (
+	ErrInstallmentNotNumber    = errors.New("installment is not a number")
+	ErrInstallmentInconsistent = errors.New("installment does not match loan amount, interest rate and term")
+	ErrGradeRateInconsistent   = errors.New("interest rate is outside the band for the subgrade")
+	ErrFundedExceedsLoanAmount = errors.New("funding amount is greater than loan amount")
+	ErrFICORangeWidthInvalid   = errors.New("FICO range high is not 4 points above FICO range low")
+)
+
+// Loan status and payment history errors
+var (
+	ErrLoanStatusUnknown                = errors.New("loan status is not a known status")
+	ErrOutPrncpNotNumber                = errors.New("outstanding principal is not a number")
+	ErrTotalPymntNotNumber              = errors.New("total payment is not a number")
+	ErrTotalRecPrncpNotNumber           = errors.New("total received principal is not a number")
+	ErrTotalRecIntNotNumber             = errors.New("total received interest is not a number")
+	ErrTotalRecLateFeeNotNumber         = errors.New("total received late fees is not a number")
+	ErrRecoveriesNotNumber              = errors.New("recoveries is not a number")
+	ErrRecoveriesMissing                = errors.New("loan is Charged Off but recoveries are not populated")
+	ErrTotalPymntMismatch               = errors.New("total payment does not equal principal, interest, late fees and recoveries received")
+	ErrFullyPaidOutstandingPrincipal    = errors.New("loan is Fully Paid but outstanding principal is not zero")
+	ErrFullyPaidPrincipalMismatch       = errors.New("loan is Fully Paid but received principal does not match funded amount")
+	ErrFullyPaidNoLastPayment           = errors.New("loan is Fully Paid but has no last payment date")
+	ErrActiveLoanNoOutstandingPrincipal = errors.New("loan is active but outstanding principal is not positive")
+)
+
+// File-level aggregate errors
+var (
+	ErrMonthlyFundedBelowMin = errors.New("total funded amount for the month is below the minimum")
+	ErrMonthlyFundedAboveMax = errors.New("total funded amount for the month is above the maximum")
+	ErrGradeDrift            = errors.New("grade share drifted from the baseline")
+	ErrRowCountMismatch      = errors.New("row count does not match the expected row count")
+)
@@ -0,0 +1,48 @@
+// Package formats holds how the Lending Club export writes numbers and dates, and resolves a run's
+// NumberFormats and DateLayouts overrides against those defaults. It is shared by loan_info and its subpackages.
+package formats
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"time"
+)
+
+// MonthLayouts covers the export's "Aug-2003" format and the ISO month form.
+var MonthLayouts = []string{"Jan-2006", utils.ISOMonthLayout}
+
+// Amount is the format of the export's dollar amounts, which may carry a "$" and thousands separators.
+var Amount = utils.NumberFormat{CurrencyPrefix: "$", GroupingSeparator: ","}
+
+// Percent is the format of the export's rates and ratios, which may carry a trailing "%".
+var Percent = utils.NumberFormat{PercentSuffix: true}
+
+// Number returns the run's NumberFormats entry for column, or def when it has none.
+func Number(vCtx *validator.RowValidatorContext, column string, def utils.NumberFormat) utils.NumberFormat {
+	if vCtx.Config != nil {
+		if f, ok := vCtx.Config.NumberFormat(column); ok {
+			return f
+		}
+	}
+	return def
+}
+
+// DateLayouts returns the run's DateLayouts entry for column, or def when it has none.
+func DateLayouts(vCtx *validator.RowValidatorContext, column string, def []string) []string {
+	if vCtx.Config != nil {
+		if l, ok := vCtx.Config.ColumnDateLayouts(column); ok {
+			return l
+		}
+	}
+	return def
+}
+
+// ParseDecimal normalizes value with column's number format, def unless the run overrides it, and parses it.
+func ParseDecimal(vCtx *validator.RowValidatorContext, column string, def utils.NumberFormat, value string) (utils.Decimal, error) {
+	return Number(vCtx, column, def).ParseDecimal(value)
+}
+
+// ParseDate parses value with column's layouts, def unless the run overrides them, and returns it with its ISO form.
+func ParseDate(vCtx *validator.RowValidatorContext, column string, def []string, value string) (time.Time, string, error) {
+	return utils.NormalizeDate(value, DateLayouts(vCtx, column, def))
+}
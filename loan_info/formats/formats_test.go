@@ -0,0 +1,50 @@
+package formats
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	override := &config.ParserConfig{NumberFormats: map[string]utils.NumberFormat{
+		"amount": {CurrencyPrefix: "€", GroupingSeparator: ".", DecimalComma: true},
+	}}
+	tests := []struct {
+		name  string
+		conf  *config.ParserConfig
+		value string
+		want  string
+	}{
+		{name: "default without config", value: "$1,500.25", want: "1500.25"},
+		{name: "default for other columns", conf: &config.ParserConfig{}, value: "$1,500.25", want: "1500.25"},
+		{name: "config override", conf: override, value: "€1.500,25", want: "1500.25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDecimal(&validator.RowValidatorContext{Config: tt.conf}, "amount", Amount, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	override := &config.ParserConfig{DateLayouts: map[string][]string{"start": {"01/2006"}}}
+	vCtx := &validator.RowValidatorContext{Config: override}
+
+	if _, iso, err := ParseDate(&validator.RowValidatorContext{}, "start", MonthLayouts, "Sep-2017"); err != nil || iso != "2017-09" {
+		t.Errorf("expected 2017-09 with the default layouts, got %q, %v", iso, err)
+	}
+	if _, iso, err := ParseDate(vCtx, "start", MonthLayouts, "09/2017"); err != nil || iso != "2017-09" {
+		t.Errorf("expected 2017-09 with the configured layout, got %q, %v", iso, err)
+	}
+	if _, _, err := ParseDate(vCtx, "start", MonthLayouts, "Sep-2017"); err == nil {
+		t.Errorf("expected the configured layouts to replace the defaults")
+	}
+}
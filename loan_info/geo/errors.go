@@ -0,0 +1,12 @@
+package geo
+
+import "errors"
+
+// Address validation errors
+var (
+	ErrAddrStateUnknown     = errors.New("address state is not a US state or territory")
+	ErrAddrStateNotLicensed = errors.New("address state is not in the licensed states")
+	ErrZipCodeInvalid       = errors.New("zip code is not a 3-digit prefix followed by xx or 2 digits")
+	ErrZipPrefixUnknown     = errors.New("zip code prefix is not assigned to any state")
+	ErrZipPrefixMismatch    = errors.New("zip code prefix does not belong to the address state")
+)
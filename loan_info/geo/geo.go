@@ -0,0 +1,85 @@
+// Package geo validates the addr_state and masked zip_code columns of a loan row.
+package geo
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// States are the two-letter postal codes accepted in addr_state:
+// the 50 states, the District of Columbia, inhabited territories and military post offices.
+var States = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "FL": "Florida", "GA": "Georgia",
+	"HI": "Hawaii", "ID": "Idaho", "IL": "Illinois", "IN": "Indiana", "IA": "Iowa",
+	"KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana", "ME": "Maine", "MD": "Maryland",
+	"MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota", "MS": "Mississippi", "MO": "Missouri",
+	"MT": "Montana", "NE": "Nebraska", "NV": "Nevada", "NH": "New Hampshire", "NJ": "New Jersey",
+	"NM": "New Mexico", "NY": "New York", "NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio",
+	"OK": "Oklahoma", "OR": "Oregon", "PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina",
+	"SD": "South Dakota", "TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont",
+	"VA": "Virginia", "WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+	"DC": "District of Columbia",
+	"PR": "Puerto Rico", "VI": "U.S. Virgin Islands", "GU": "Guam", "AS": "American Samoa",
+	"MP": "Northern Mariana Islands",
+	"AA": "Armed Forces Americas", "AE": "Armed Forces Europe", "AP": "Armed Forces Pacific",
+}
+
+//go:embed zip_prefixes.csv
+var zipPrefixTable string
+
+// zipPrefixStates maps a three-digit ZIP prefix to the states it serves.
+var zipPrefixStates = mustParseZipPrefixes(zipPrefixTable)
+
+// IsState reports whether code is a known state or territory postal code.
+func IsState(code string) bool {
+	_, ok := States[code]
+	return ok
+}
+
+// StatesForZipPrefix returns the states served by a three-digit ZIP prefix such as "941".
+func StatesForZipPrefix(prefix string) []string {
+	return zipPrefixStates[prefix]
+}
+
+func mustParseZipPrefixes(table string) map[string][]string {
+	prefixes, err := parseZipPrefixes(table)
+	if err != nil {
+		panic(err)
+	}
+	return prefixes
+}
+
+// parseZipPrefixes reads "first,last,state" lines; blank lines and lines starting with '#' are skipped.
+func parseZipPrefixes(table string) (map[string][]string, error) {
+	prefixes := make(map[string][]string)
+	for n, line := range strings.Split(table, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("zip prefix table line %d: expected 3 fields, got %d", n+1, len(fields))
+		}
+		first, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("zip prefix table line %d: %w", n+1, err)
+		}
+		last, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("zip prefix table line %d: %w", n+1, err)
+		}
+		state := fields[2]
+		if !IsState(state) {
+			return nil, fmt.Errorf("zip prefix table line %d: unknown state %q", n+1, state)
+		}
+		for p := first; p <= last; p++ {
+			key := fmt.Sprintf("%03d", p)
+			prefixes[key] = append(prefixes[key], state)
+		}
+	}
+	return prefixes, nil
+}
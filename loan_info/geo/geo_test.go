@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"slices"
+	"testing"
+)
+
+func mockGetMap() map[string]string {
+	return make(map[string]string)
+}
+
+func addressCols(zip, state string) []string {
+	cols := make([]string, 24)
+	cols[colZipCode] = zip
+	cols[colAddrState] = state
+	return cols
+}
+
+func TestZipPrefixTable(t *testing.T) {
+	testCases := []struct {
+		prefix string
+		state  string
+	}{
+		{prefix: "190", state: "PA"},
+		{prefix: "941", state: "CA"},
+		{prefix: "029", state: "RI"},
+		{prefix: "055", state: "MA"},
+		{prefix: "201", state: "VA"},
+		{prefix: "733", state: "TX"},
+		{prefix: "885", state: "TX"},
+		{prefix: "569", state: "DC"},
+		{prefix: "969", state: "GU"},
+		{prefix: "969", state: "MP"},
+		{prefix: "995", state: "AK"},
+	}
+	for _, tc := range testCases {
+		if !slices.Contains(StatesForZipPrefix(tc.prefix), tc.state) {
+			t.Errorf("expected prefix %s to serve %s, got %v", tc.prefix, tc.state, StatesForZipPrefix(tc.prefix))
+		}
+	}
+	// Gaps in the USPS assignments
+	for _, prefix := range []string{"000", "213", "899"} {
+		if states := StatesForZipPrefix(prefix); len(states) != 0 {
+			t.Errorf("expected prefix %s to be unassigned, got %v", prefix, states)
+		}
+	}
+}
+
+func TestParseZipPrefixes_Errors(t *testing.T) {
+	for _, table := range []string{"010,027", "abc,027,MA", "010,xyz,MA", "010,027,ZZ"} {
+		if _, err := parseZipPrefixes(table); err == nil {
+			t.Errorf("expected error for table %q", table)
+		}
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		state    string
+		licensed []string
+		errMsg   string
+	}{
+		{name: "state", state: "PA"},
+		{name: "lower case state", state: "pa"},
+		{name: "territory", state: "PR"},
+		{name: "unknown state", state: "XX", errMsg: "address state is not a US state or territory"},
+		{name: "empty state", state: "", errMsg: "address state is not a US state or territory"},
+		{name: "licensed state", state: "PA", licensed: []string{"PA", "NJ"}},
+		{name: "unlicensed state", state: "SD", licensed: []string{"PA", "NJ"}, errMsg: "address state is not in the licensed states"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{LicensedStates: tc.licensed},
+				GetMap: mockGetMap,
+			}
+
+			result, err := ValidateState(ctx, addressCols("", tc.state))
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["addrState"] == "" {
+				t.Errorf("expected addrState to be cached")
+			}
+		})
+	}
+}
+
+func TestValidateZipCode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		zip    string
+		state  string
+		errMsg string
+	}{
+		{name: "masked zip", zip: "190xx", state: "PA"},
+		{name: "upper case mask", zip: "941XX", state: "CA"},
+		{name: "full zip", zip: "94105", state: "CA"},
+		{name: "shared prefix", zip: "969xx", state: "MP"},
+		{name: "prefix in another state", zip: "190xx", state: "NJ", errMsg: "zip code prefix does not belong to the address state"},
+		{name: "unassigned prefix", zip: "000xx", state: "PA", errMsg: "zip code prefix is not assigned to any state"},
+		{name: "too short", zip: "19xx", state: "PA", errMsg: "zip code is not a 3-digit prefix followed by xx or 2 digits"},
+		{name: "letters in prefix", zip: "1a0xx", state: "PA", errMsg: "zip code is not a 3-digit prefix followed by xx or 2 digits"},
+		{name: "partial mask", zip: "1901x", state: "PA", errMsg: "zip code is not a 3-digit prefix followed by xx or 2 digits"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := ValidateZipCode(ctx, addressCols(tc.zip, tc.state))
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["zipPrefix"] != tc.zip[:3] {
+				t.Errorf("expected zipPrefix '%s', got '%s'", tc.zip[:3], result["zipPrefix"])
+			}
+		})
+	}
+}
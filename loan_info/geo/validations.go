@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"slices"
+	"strings"
+)
+
+// Column index constants for address fields
+const (
+	colZipCode   = 22
+	colAddrState = 23
+)
+
+// ValidateState checks that addr_state is a known postal code and, when the run
+// configures LicensedStates, that it is one of them.
+func ValidateState(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	state := strings.ToUpper(utils.TrimIfNeeded(cols[colAddrState]))
+	if !IsState(state) {
+		return nil, ErrAddrStateUnknown
+	}
+	if vCtx.Config != nil && !vCtx.Config.IsLicensedState(state) {
+		return nil, ErrAddrStateNotLicensed
+	}
+	result := vCtx.GetMap()
+	result["addrState"] = state
+	return result, nil
+}
+
+// ValidateZipCode checks that zip_code is a masked ("941xx") or full five-digit ZIP code
+// whose three-digit prefix serves addr_state.
+func ValidateZipCode(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	zip := strings.ToLower(utils.TrimIfNeeded(cols[colZipCode]))
+	if !isZipCode(zip) {
+		return nil, ErrZipCodeInvalid
+	}
+	prefix := zip[:3]
+	states := StatesForZipPrefix(prefix)
+	if len(states) == 0 {
+		return nil, ErrZipPrefixUnknown
+	}
+	state := strings.ToUpper(utils.TrimIfNeeded(cols[colAddrState]))
+	if !slices.Contains(states, state) {
+		return nil, ErrZipPrefixMismatch
+	}
+	result := vCtx.GetMap()
+	result["zipPrefix"] = prefix
+	return result, nil
+}
+
+// isZipCode accepts "941xx" and "94105".
+func isZipCode(zip string) bool {
+	if len(zip) != 5 {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		if zip[i] < '0' || zip[i] > '9' {
+			return false
+		}
+	}
+	if zip[3:] == "xx" {
+		return true
+	}
+	return zip[3] >= '0' && zip[3] <= '9' && zip[4] >= '0' && zip[4] <= '9'
+}
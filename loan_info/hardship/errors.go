@@ -0,0 +1,33 @@
+package hardship
+
+import "errors"
+
+// Hardship validation errors
+var (
+	ErrHardshipFlagInvalid      = errors.New("hardship flag is not Y or N")
+	ErrHardshipDatesMissing     = errors.New("hardship flag is Y but hardship dates are missing")
+	ErrHardshipStillActive      = errors.New("hardship flag is N but hardship status is ACTIVE")
+	ErrHardshipDateFormat       = errors.New("hardship date does not match a configured date layout")
+	ErrHardshipDatesOutOfOrder  = errors.New("hardship start date is not before hardship end date")
+	ErrHardshipAmountNotNumber  = errors.New("hardship amount is not a number")
+	ErrHardshipAmountNegative   = errors.New("hardship amount is negative")
+	ErrHardshipPayoffNotNumber  = errors.New("hardship payoff balance is not a number")
+	ErrHardshipPayoffNegative   = errors.New("hardship payoff balance is negative")
+	ErrHardshipPaymentNotNumber = errors.New("hardship last payment amount is not a number")
+	ErrHardshipPaymentNegative  = errors.New("hardship last payment amount is negative")
+)
+
+// Debt settlement validation errors
+var (
+	ErrSettlementFlagInvalid       = errors.New("debt settlement flag is not Y or N")
+	ErrSettlementFieldsWithoutFlag = errors.New("settlement fields are populated but debt settlement flag is N")
+	ErrSettlementStatusEmpty       = errors.New("debt settlement flag is Y but settlement status is empty")
+	ErrSettlementDateFormat        = errors.New("settlement date does not match a configured date layout")
+	ErrSettlementAmountNotNumber   = errors.New("settlement amount is not a number")
+	ErrSettlementAmountNotPositive = errors.New("settlement amount is not a positive number")
+	ErrSettlementPercentNotNumber  = errors.New("settlement percentage is not a number")
+	ErrSettlementPercentOutOfRange = errors.New("settlement percentage is not between 0 and 100")
+	ErrSettlementTermNotNumber     = errors.New("settlement term is not a number")
+	ErrSettlementTermNegative      = errors.New("settlement term is negative")
+	ErrSettlementFlagDateFormat    = errors.New("debt settlement flag date does not match a configured date layout")
+)
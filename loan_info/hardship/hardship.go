@@ -0,0 +1,124 @@
+// Package hardship validates the hardship plan and debt settlement columns of a loan row.
+package hardship
+
+import (
+	"encoding/json"
+	"go-file-parsing/loan_info/formats"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+)
+
+// Column index constants for hardship fields
+const (
+	colHardshipFlag          = 128
+	colHardshipType          = 129
+	colHardshipReason        = 130
+	colHardshipStatus        = 131
+	colHardshipAmount        = 133
+	colHardshipStartDate     = 134
+	colHardshipEndDate       = 135
+	colHardshipLength        = 137
+	colHardshipDPD           = 138
+	colHardshipLoanStatus    = 139
+	colHardshipPayoffBalance = 141
+	colHardshipLastPayment   = 142
+)
+
+const hardshipStatusActive = "ACTIVE"
+
+// Summary is the compact hardship record cached under the "hardship" field.
+type Summary struct {
+	Type       string `json:"type,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Length     string `json:"length,omitempty"`
+	Amount     string `json:"amount,omitempty"`
+	DPD        string `json:"dpd,omitempty"`
+	LoanStatus string `json:"loanStatus,omitempty"`
+}
+
+// ValidateHardship checks the hardship columns and caches a summary for loans that had a hardship plan.
+// hardship_flag is Y or N; Y requires start and end dates; N is only allowed with dates when the plan is no
+// longer ACTIVE; the start date is before the end date; and the amounts are not negative.
+func ValidateHardship(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	flag := utils.TrimIfNeeded(cols[colHardshipFlag])
+	if flag != "Y" && flag != "N" {
+		return nil, ErrHardshipFlagInvalid
+	}
+
+	startStr := utils.TrimIfNeeded(cols[colHardshipStartDate])
+	endStr := utils.TrimIfNeeded(cols[colHardshipEndDate])
+	status := utils.TrimIfNeeded(cols[colHardshipStatus])
+	hasDates := startStr != "" || endStr != ""
+
+	if flag == "Y" && (startStr == "" || endStr == "") {
+		return nil, ErrHardshipDatesMissing
+	}
+	if !hasDates {
+		// No hardship plan on this loan; nothing else to check
+		return nil, nil
+	}
+	if flag == "N" && status == hardshipStatusActive {
+		return nil, ErrHardshipStillActive
+	}
+
+	start, startISO, err := formats.ParseDate(vCtx, "hardship_start_date", formats.MonthLayouts, startStr)
+	if err != nil {
+		return nil, ErrHardshipDateFormat
+	}
+	end, endISO, err := formats.ParseDate(vCtx, "hardship_end_date", formats.MonthLayouts, endStr)
+	if err != nil {
+		return nil, ErrHardshipDateFormat
+	}
+	if !start.Before(end) {
+		return nil, ErrHardshipDatesOutOfOrder
+	}
+
+	summary := Summary{
+		Type:       utils.TrimIfNeeded(cols[colHardshipType]),
+		Reason:     utils.TrimIfNeeded(cols[colHardshipReason]),
+		Status:     status,
+		Start:      startISO,
+		End:        endISO,
+		Length:     utils.TrimIfNeeded(cols[colHardshipLength]),
+		DPD:        utils.TrimIfNeeded(cols[colHardshipDPD]),
+		LoanStatus: utils.TrimIfNeeded(cols[colHardshipLoanStatus]),
+	}
+
+	amounts := []struct {
+		column    string
+		index     int
+		notNumber error
+		negative  error
+	}{
+		{"hardship_amount", colHardshipAmount, ErrHardshipAmountNotNumber, ErrHardshipAmountNegative},
+		{"hardship_payoff_balance_amount", colHardshipPayoffBalance, ErrHardshipPayoffNotNumber, ErrHardshipPayoffNegative},
+		{"hardship_last_payment_amount", colHardshipLastPayment, ErrHardshipPaymentNotNumber, ErrHardshipPaymentNegative},
+	}
+	for _, a := range amounts {
+		raw := utils.TrimIfNeeded(cols[a.index])
+		if raw == "" {
+			continue
+		}
+		d, err := formats.ParseDecimal(vCtx, a.column, formats.Amount, raw)
+		if err != nil {
+			return nil, a.notNumber
+		}
+		if d.Sign() < 0 {
+			return nil, a.negative
+		}
+		if a.index == colHardshipAmount {
+			summary.Amount = d.String()
+		}
+	}
+
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	result := vCtx.GetMap()
+	result["hardship"] = string(encoded)
+	return result, nil
+}
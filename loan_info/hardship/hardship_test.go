@@ -0,0 +1,132 @@
+package hardship
+
+import (
+	"encoding/json"
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+func mockGetMap() map[string]string {
+	return make(map[string]string)
+}
+
+// Helper function to create a full-width row with specific values at specific indices
+func createCols(values map[int]string) []string {
+	cols := make([]string, 151)
+	for idx, val := range values {
+		cols[idx] = val
+	}
+	return cols
+}
+
+func TestValidateHardship(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cols     []string
+		errMsg   string
+		expected *Summary
+	}{
+		{
+			name: "no hardship",
+			cols: createCols(map[int]string{128: "N"}),
+		},
+		{
+			name: "active hardship",
+			cols: createCols(map[int]string{
+				128: "Y", 129: "INTEREST ONLY-3 MONTHS DEFERRAL", 130: "INCOME_CURTAILMENT", 131: "ACTIVE",
+				133: "95.52", 134: "Feb-2019", 135: "May-2019", 137: "3.0", 138: "0.0", 139: "Current",
+				141: "3769.33", 142: "0.0",
+			}),
+			expected: &Summary{
+				Type: "INTEREST ONLY-3 MONTHS DEFERRAL", Reason: "INCOME_CURTAILMENT", Status: "ACTIVE",
+				Start: "2019-02", End: "2019-05", Length: "3.0", Amount: "95.52", DPD: "0.0", LoanStatus: "Current",
+			},
+		},
+		{
+			name: "completed hardship with flag N",
+			cols: createCols(map[int]string{
+				128: "N", 131: "COMPLETED", 133: "120.00", 134: "Sep-2017", 135: "Dec-2017",
+			}),
+			expected: &Summary{Status: "COMPLETED", Start: "2017-09", End: "2017-12", Amount: "120"},
+		},
+		{
+			name:   "invalid flag",
+			cols:   createCols(map[int]string{128: ""}),
+			errMsg: "hardship flag is not Y or N",
+		},
+		{
+			name:   "flag Y without dates",
+			cols:   createCols(map[int]string{128: "Y", 131: "ACTIVE"}),
+			errMsg: "hardship flag is Y but hardship dates are missing",
+		},
+		{
+			name:   "flag N with active plan",
+			cols:   createCols(map[int]string{128: "N", 131: "ACTIVE", 134: "Feb-2019", 135: "May-2019"}),
+			errMsg: "hardship flag is N but hardship status is ACTIVE",
+		},
+		{
+			name:   "unparseable start date",
+			cols:   createCols(map[int]string{128: "Y", 134: "2019/02", 135: "May-2019"}),
+			errMsg: "hardship date does not match a configured date layout",
+		},
+		{
+			name:   "start after end",
+			cols:   createCols(map[int]string{128: "Y", 134: "May-2019", 135: "Feb-2019"}),
+			errMsg: "hardship start date is not before hardship end date",
+		},
+		{
+			name:   "negative hardship amount",
+			cols:   createCols(map[int]string{128: "Y", 133: "-1.00", 134: "Feb-2019", 135: "May-2019"}),
+			errMsg: "hardship amount is negative",
+		},
+		{
+			name:   "hardship amount not a number",
+			cols:   createCols(map[int]string{128: "Y", 133: "n/a", 134: "Feb-2019", 135: "May-2019"}),
+			errMsg: "hardship amount is not a number",
+		},
+		{
+			name:   "negative payoff balance",
+			cols:   createCols(map[int]string{128: "Y", 134: "Feb-2019", 135: "May-2019", 141: "-5"}),
+			errMsg: "hardship payoff balance is negative",
+		},
+		{
+			name:   "negative last payment",
+			cols:   createCols(map[int]string{128: "Y", 134: "Feb-2019", 135: "May-2019", 142: "-5"}),
+			errMsg: "hardship last payment amount is negative",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := ValidateHardship(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expected == nil {
+				if len(result) != 0 {
+					t.Errorf("expected no cached fields, got %v", result)
+				}
+				return
+			}
+			var summary Summary
+			if err := json.Unmarshal([]byte(result["hardship"]), &summary); err != nil {
+				t.Fatalf("hardship summary is not valid JSON: %v", err)
+			}
+			if summary != *tc.expected {
+				t.Errorf("expected summary %+v, got %+v", *tc.expected, summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package hardship
+
+import (
+	"go-file-parsing/loan_info/formats"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"strconv"
+)
+
+// Column index constants for debt settlement fields
+const (
+	colDebtSettlementFlag     = 144
+	colDebtSettlementFlagDate = 145
+	colSettlementStatus       = 146
+	colSettlementDate         = 147
+	colSettlementAmount       = 148
+	colSettlementPercentage   = 149
+	colSettlementTerm         = 150
+)
+
+var maxSettlementPercentage = utils.NewDecimal(100)
+
+// ValidateSettlement checks the debt settlement columns.
+// debt_settlement_flag is Y or N; N requires the settlement columns to be empty; Y requires a status,
+// parseable dates, a positive amount, a percentage in (0, 100] and a non-negative term when one is given.
+func ValidateSettlement(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	flag := utils.TrimIfNeeded(cols[colDebtSettlementFlag])
+	if flag != "Y" && flag != "N" {
+		return nil, ErrSettlementFlagInvalid
+	}
+
+	status := utils.TrimIfNeeded(cols[colSettlementStatus])
+	if flag == "N" {
+		for _, i := range []int{colSettlementStatus, colSettlementDate, colSettlementAmount, colSettlementPercentage, colSettlementTerm} {
+			if utils.TrimIfNeeded(cols[i]) != "" {
+				return nil, ErrSettlementFieldsWithoutFlag
+			}
+		}
+		return nil, nil
+	}
+
+	if status == "" {
+		return nil, ErrSettlementStatusEmpty
+	}
+	result := vCtx.GetMap()
+	result["settlementStatus"] = status
+
+	if flagDate := utils.TrimIfNeeded(cols[colDebtSettlementFlagDate]); flagDate != "" {
+		_, iso, err := formats.ParseDate(vCtx, "debt_settlement_flag_date", formats.MonthLayouts, flagDate)
+		if err != nil {
+			validator.PutMap(result)
+			return nil, ErrSettlementFlagDateFormat
+		}
+		result["debtSettlementFlagDate"] = iso
+	}
+	if settlementDate := utils.TrimIfNeeded(cols[colSettlementDate]); settlementDate != "" {
+		_, iso, err := formats.ParseDate(vCtx, "settlement_date", formats.MonthLayouts, settlementDate)
+		if err != nil {
+			validator.PutMap(result)
+			return nil, ErrSettlementDateFormat
+		}
+		result["settlementDate"] = iso
+	}
+
+	amount, err := formats.ParseDecimal(vCtx, "settlement_amount", formats.Amount, cols[colSettlementAmount])
+	if err != nil {
+		validator.PutMap(result)
+		return nil, ErrSettlementAmountNotNumber
+	}
+	if amount.Sign() <= 0 {
+		validator.PutMap(result)
+		return nil, ErrSettlementAmountNotPositive
+	}
+	result["settlementAmount"] = amount.String()
+
+	percentage, err := formats.ParseDecimal(vCtx, "settlement_percentage", formats.Percent, cols[colSettlementPercentage])
+	if err != nil {
+		validator.PutMap(result)
+		return nil, ErrSettlementPercentNotNumber
+	}
+	if percentage.Sign() <= 0 || percentage.Cmp(maxSettlementPercentage) > 0 {
+		validator.PutMap(result)
+		return nil, ErrSettlementPercentOutOfRange
+	}
+	result["settlementPercentage"] = percentage.String()
+
+	if termStr := utils.TrimIfNeeded(cols[colSettlementTerm]); termStr != "" {
+		term, err := utils.FormattedStringToInt(&termStr)
+		if err != nil {
+			validator.PutMap(result)
+			return nil, ErrSettlementTermNotNumber
+		}
+		if term < 0 {
+			validator.PutMap(result)
+			return nil, ErrSettlementTermNegative
+		}
+		result["settlementTerm"] = strconv.Itoa(term)
+	}
+	return result, nil
+}
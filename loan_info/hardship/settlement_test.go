@@ -0,0 +1,124 @@
+package hardship
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+func TestValidateSettlement(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cols     []string
+		errMsg   string
+		expected map[string]string
+	}{
+		{
+			name:     "no settlement",
+			cols:     createCols(map[int]string{144: "N"}),
+			expected: map[string]string{},
+		},
+		{
+			name: "settled loan",
+			cols: createCols(map[int]string{
+				144: "Y", 145: "Feb-2019", 146: "ACTIVE", 147: "Mar-2019", 148: "2456.00", 149: "45.0", 150: "18.0",
+			}),
+			expected: map[string]string{
+				"settlementStatus":       "ACTIVE",
+				"debtSettlementFlagDate": "2019-02",
+				"settlementDate":         "2019-03",
+				"settlementAmount":       "2456",
+				"settlementPercentage":   "45",
+				"settlementTerm":         "18",
+			},
+		},
+		{
+			name:   "invalid flag",
+			cols:   createCols(map[int]string{144: "maybe"}),
+			errMsg: "debt settlement flag is not Y or N",
+		},
+		{
+			name:   "settlement fields without flag",
+			cols:   createCols(map[int]string{144: "N", 148: "100"}),
+			errMsg: "settlement fields are populated but debt settlement flag is N",
+		},
+		{
+			name:   "flag without status",
+			cols:   createCols(map[int]string{144: "Y", 148: "100", 149: "50"}),
+			errMsg: "debt settlement flag is Y but settlement status is empty",
+		},
+		{
+			name:   "unparseable settlement date",
+			cols:   createCols(map[int]string{144: "Y", 146: "COMPLETE", 147: "03/2019", 148: "100", 149: "50"}),
+			errMsg: "settlement date does not match a configured date layout",
+		},
+		{
+			name:   "zero settlement amount",
+			cols:   createCols(map[int]string{144: "Y", 146: "COMPLETE", 148: "0", 149: "50"}),
+			errMsg: "settlement amount is not a positive number",
+		},
+		{
+			name:   "percentage over 100",
+			cols:   createCols(map[int]string{144: "Y", 146: "COMPLETE", 148: "100", 149: "100.01"}),
+			errMsg: "settlement percentage is not between 0 and 100",
+		},
+		{
+			name:   "percentage missing",
+			cols:   createCols(map[int]string{144: "Y", 146: "COMPLETE", 148: "100"}),
+			errMsg: "settlement percentage is not a number",
+		},
+		{
+			name:   "negative term",
+			cols:   createCols(map[int]string{144: "Y", 146: "COMPLETE", 148: "100", 149: "50", 150: "-1"}),
+			errMsg: "settlement term is negative",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := ValidateSettlement(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result) != len(tc.expected) {
+				t.Errorf("expected %d entries in result, got %d", len(tc.expected), len(result))
+			}
+			for key, expectedValue := range tc.expected {
+				if result[key] != expectedValue {
+					t.Errorf("expected %s to be '%s', got '%s'", key, expectedValue, result[key])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSettlement_ConfiguredNumberFormat(t *testing.T) {
+	ctx := &validator.RowValidatorContext{
+		Config: &config.ParserConfig{
+			NumberFormats: map[string]utils.NumberFormat{
+				"settlement_percentage": {PercentSuffix: true, DecimalComma: true},
+			},
+		},
+		GetMap: mockGetMap,
+	}
+	cols := createCols(map[int]string{144: "Y", 146: "ACTIVE", 148: "2456.00", 149: "45,5 %"})
+	result, err := ValidateSettlement(ctx, cols)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["settlementPercentage"] != "45.5" {
+		t.Errorf("expected settlementPercentage '45.5', got '%s'", result["settlementPercentage"])
+	}
+}
@@ -0,0 +1,142 @@
+package loan_info
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+)
+
+// Column index constants for application type and secondary applicant fields
+const (
+	colApplicationType         = 56
+	colAnnualIncJoint          = 57
+	colDTIJoint                = 58
+	colVerificationStatusJoint = 59
+	colSecAppFICORangeLow      = 116
+	colSecAppFICORangeHigh     = 117
+	colSecAppEarliestCrLine    = 118
+	// The sec_app_* columns run from sec_app_fico_range_low to sec_app_mths_since_last_major_derog
+	colSecAppLast = 127
+)
+
+const jointApplicationType = "Joint App"
+
+// isJointApplication reports whether the row is a joint application. Joint rules pass individual rows through.
+func isJointApplication(cols []string) bool {
+	return utils.TrimIfNeeded(cols[colApplicationType]) == jointApplicationType
+}
+
+// hasSecondaryApplicant reports whether any sec_app_* column is filled. Exports from before 2017 leave them
+// blank even for joint applications, so the secondary applicant rules pass those rows through.
+func hasSecondaryApplicant(cols []string) bool {
+	for _, col := range cols[colSecAppFICORangeLow:min(colSecAppLast+1, len(cols))] {
+		if utils.TrimIfNeeded(col) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule 13: Joint Income and DTI
+// For joint applications, annual_inc_joint > 30,000 and dti_joint <= 20.
+func hasValidJointIncomeAndDTI(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	if !isJointApplication(cols) {
+		return nil, nil
+	}
+
+	result := vCtx.GetMap()
+	annualIncJoint := utils.TrimIfNeeded(cols[colAnnualIncJoint])
+	err := validateDecimal(vCtx, "annual_inc_joint", &annualIncJoint, ErrAnnualIncJointNotNumber, func(d utils.Decimal) error {
+		if d.Cmp(minAnnualIncome) <= 0 {
+			return ErrAnnualIncJointTooLow
+		}
+		return nil
+	})
+	if err != nil {
+		validator.PutMap(result)
+		return nil, err
+	}
+
+	dtiJoint := utils.TrimIfNeeded(cols[colDTIJoint])
+	err = validateDecimal(vCtx, "dti_joint", &dtiJoint, ErrDTIJointNotNumber, func(d utils.Decimal) error {
+		if d.Cmp(maxDTI) > 0 {
+			return ErrDTIJointTooHigh
+		}
+		result["dtiJoint"] = dtiJoint
+		return nil
+	})
+	if err != nil {
+		validator.PutMap(result)
+		return nil, err
+	}
+
+	if verificationStatusJoint := utils.TrimIfNeeded(cols[colVerificationStatusJoint]); verificationStatusJoint != "" {
+		result["verificationStatusJoint"] = verificationStatusJoint
+	}
+	return result, nil
+}
+
+// Rule 14: Secondary Applicant FICO Score
+// For joint applications with secondary applicant data, sec_app_fico_range_low >= 660 and sec_app_fico_range_high <= 850.
+func hasHealthySecondaryFICOScore(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	if !isJointApplication(cols) || !hasSecondaryApplicant(cols) {
+		return nil, nil
+	}
+
+	result := vCtx.GetMap()
+	ficoLow := utils.TrimIfNeeded(cols[colSecAppFICORangeLow])
+	err := validateFormattedInt(&ficoLow, ErrSecAppFICORangeLowNotNumber, func(i int) error {
+		if i < 660 {
+			return ErrSecAppFICORangeLowTooLow
+		}
+		result["secAppFicoRangeLow"] = ficoLow
+		return nil
+	})
+	if err != nil {
+		validator.PutMap(result)
+		return nil, err
+	}
+
+	ficoHigh := utils.TrimIfNeeded(cols[colSecAppFICORangeHigh])
+	err = validateFormattedInt(&ficoHigh, ErrSecAppFICORangeHighNotNumber, func(i int) error {
+		if i > 850 {
+			return ErrSecAppFICORangeHighTooHigh
+		}
+		result["secAppFicoRangeHigh"] = ficoHigh
+		return nil
+	})
+	if err != nil {
+		validator.PutMap(result)
+		return nil, err
+	}
+	return result, nil
+}
+
+// Rule 15: Secondary Applicant Credit History
+// For joint applications with secondary applicant data, sec_app_earliest_cr_line not null and is > 10 years
+// before the as-of date.
+func hasEstablishedSecondaryCreditHistory(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	if !isJointApplication(cols) || !hasSecondaryApplicant(cols) {
+		return nil, nil
+	}
+
+	earliestCRLine := utils.TrimIfNeeded(cols[colSecAppEarliestCrLine])
+	if earliestCRLine == "" {
+		return nil, ErrSecAppEarliestCrLineEmpty
+	}
+	workTime, isoDate, err := parseDateColumn(vCtx, "sec_app_earliest_cr_line", earliestCRLine)
+	if err != nil {
+		return nil, ErrSecAppEarliestCrLineFormat
+	}
+
+	asOf, err := asOfDate(vCtx, cols)
+	if err != nil {
+		return nil, err
+	}
+	if workTime.After(asOf.AddDate(-creditHistoryYears, 0, 0)) {
+		return nil, ErrSecAppEarliestCrLineTooRecent
+	}
+
+	result := vCtx.GetMap()
+	result["secAppEarliestCrLine"] = isoDate
+	return result, nil
+}
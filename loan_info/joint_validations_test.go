@@ -0,0 +1,236 @@
+package loan_info
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHasValidJointIncomeAndDTI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cols     []string
+		errMsg   string
+		expected map[string]string
+	}{
+		{
+			name:     "individual application is not checked",
+			cols:     createTestCols(56, "Individual", 57, "abc", 58, "abc", 118, ""),
+			expected: map[string]string{},
+		},
+		{
+			name: "valid joint application",
+			cols: createTestCols(56, "Joint App", 57, "110000.0", 58, "18.50", 59, "Verified", 118, ""),
+			expected: map[string]string{
+				"dtiJoint":                "18.5",
+				"verificationStatusJoint": "Verified",
+			},
+		},
+		{
+			name:   "joint income not a number",
+			cols:   createTestCols(56, "Joint App", 57, "abc", 58, "18.5", 118, ""),
+			errMsg: "joint annual income is not a number",
+		},
+		{
+			name:   "joint income too low",
+			cols:   createTestCols(56, "Joint App", 57, "30000", 58, "18.5", 118, ""),
+			errMsg: "joint annual income is not greater than 30,000",
+		},
+		{
+			name:   "joint DTI missing",
+			cols:   createTestCols(56, "Joint App", 57, "110000", 58, "", 118, ""),
+			errMsg: "joint DTI is not a number",
+		},
+		{
+			name:   "joint DTI too high",
+			cols:   createTestCols(56, "Joint App", 57, "110000", 58, "20.01", 118, ""),
+			errMsg: "joint DTI is not less than 20",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasValidJointIncomeAndDTI(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result) != len(tc.expected) {
+				t.Errorf("expected %d entries in result, got %d", len(tc.expected), len(result))
+			}
+			for key, expectedValue := range tc.expected {
+				if result[key] != expectedValue {
+					t.Errorf("expected %s to be '%s', got '%s'", key, expectedValue, result[key])
+				}
+			}
+		})
+	}
+}
+
+func TestHasHealthySecondaryFICOScore(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "individual application is not checked",
+			cols: createTestCols(56, "Individual", 116, "", 117, "", 118, ""),
+		},
+		{
+			name: "valid secondary FICO",
+			cols: createTestCols(56, "Joint App", 116, "700.0", 117, "704.0", 118, ""),
+		},
+		{
+			name:   "secondary FICO low not a number",
+			cols:   createTestCols(56, "Joint App", 116, "", 117, "704", 118, ""),
+			errMsg: "secondary applicant FICO range low is not a number",
+		},
+		{
+			name: "joint application without secondary applicant data is not checked",
+			cols: createTestCols(56, "Joint App", 116, "", 117, "", 118, ""),
+		},
+		{
+			name:   "secondary FICO low too low",
+			cols:   createTestCols(56, "Joint App", 116, "655", 117, "659", 118, ""),
+			errMsg: "secondary applicant FICO range low is less than 660",
+		},
+		{
+			name:   "secondary FICO high not a number",
+			cols:   createTestCols(56, "Joint App", 116, "700", 117, "abc", 118, ""),
+			errMsg: "secondary applicant FICO range high is not a number",
+		},
+		{
+			name:   "secondary FICO high too high",
+			cols:   createTestCols(56, "Joint App", 116, "700", 117, "851", 118, ""),
+			errMsg: "secondary applicant FICO range high is greater than 850",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasHealthySecondaryFICOScore(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if isJointApplication(tc.cols) && hasSecondaryApplicant(tc.cols) {
+				if result["secAppFicoRangeLow"] != "700" || result["secAppFicoRangeHigh"] != "704" {
+					t.Errorf("expected secondary FICO 700-704, got %v", result)
+				}
+			}
+		})
+	}
+}
+
+func TestHasEstablishedSecondaryCreditHistory(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cols     []string
+		expected string
+		errMsg   string
+	}{
+		{
+			name: "individual application is not checked",
+			cols: createTestCols(56, "Individual", 118, ""),
+		},
+		{
+			name:     "valid secondary credit history",
+			cols:     createTestCols(56, "Joint App", 118, "Aug-2003"),
+			expected: "2003-08",
+		},
+		{
+			name: "joint application without secondary applicant data is not checked",
+			cols: createTestCols(56, "Joint App", 118, ""),
+		},
+		{
+			name:   "secondary credit history empty",
+			cols:   createTestCols(56, "Joint App", 116, "700", 118, ""),
+			errMsg: "secondary applicant earliest credit line is empty",
+		},
+		{
+			name:   "secondary credit history invalid format",
+			cols:   createTestCols(56, "Joint App", 118, "2003/08"),
+			errMsg: "secondary applicant earliest credit line does not match a configured date layout",
+		},
+		{
+			name:   "secondary credit history too recent",
+			cols:   createTestCols(56, "Joint App", 118, "Jan-2012"),
+			errMsg: "secondary applicant earliest credit line is not more than 10 years ago",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: checkedConfig(t, config.ParserConfig{AsOfDate: "2018-12-31"}),
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasEstablishedSecondaryCreditHistory(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["secAppEarliestCrLine"] != tc.expected {
+				t.Errorf("expected secAppEarliestCrLine '%s', got '%s'", tc.expected, result["secAppEarliestCrLine"])
+			}
+		})
+	}
+}
+
+func TestJointRules_SampleRowWithoutSecondaryApplicant(t *testing.T) {
+	data, err := os.ReadFile("../sample.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 68341763 is a Dec-2015 joint application, from before the export had sec_app_* columns
+	var cols []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "68341763,") {
+			cols = strings.Split(line, ",")
+		}
+	}
+	if !isJointApplication(cols) {
+		t.Fatalf("expected row 68341763 to be a joint application")
+	}
+	ctx := &validator.RowValidatorContext{
+		Config: checkedConfig(t, config.ParserConfig{AsOfDate: "2018-12-31"}),
+		GetMap: mockGetMap,
+	}
+	for name, rule := range map[string]validator.ColValidator{
+		"joint_fico_score":     hasHealthySecondaryFICOScore,
+		"joint_credit_history": hasEstablishedSecondaryCreditHistory,
+	} {
+		if _, err = rule(ctx, cols); err != nil {
+			t.Errorf("expected %s to pass row 68341763, got %v", name, err)
+		}
+	}
+}
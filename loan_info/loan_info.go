@@ -1,31 +1,166 @@
 package loan_info
 
 import (
+	"fmt"
 	"go-file-parsing/config"
+	"go-file-parsing/loan_info/geo"
+	"go-file-parsing/loan_info/hardship"
 	"go-file-parsing/validator"
+	"slices"
 )
 
-var validators = []validator.ColValidator{
-	isValidSize,
-	hasValidLoanAmount,
-	hasValidInterestRate,
-	hasValidTerm,
-	hasEmploymentInfo,
-	hasEstablishedCreditHistory,
-	hasHealthyFICOScore,
-	hasSufficientAccounts,
-	isVerifiedWithIncome,
-	hasValidGradeSubgrade,
-	hasLowDTI,
-	passExtraData,
-}
-
-func NewRowValidatorPool(conf *config.ParserConfig, cacheChan chan validator.CacheData, poolSize int) chan validator.CsvRowValidator {
+// Rule is a named validation that can be enabled or disabled per run.
+type Rule struct {
+	Name      string
+	Validator validator.ColValidator
+	// Default reports whether the rule runs when the configuration does not mention it.
+	Default bool
+	// Columns are the header names of the columns the rule checks, used to report the columns that fail most.
+	Columns []string
+}
+
+// rules are the selectable validations, in the order they are registered with the row validator.
+// isValidSize always runs first and passExtraData always runs last, so they are not listed here.
+var rules = []Rule{
+	{Name: "loan_amount", Validator: hasValidLoanAmount, Default: true, Columns: []string{"loan_amnt", "funded_amnt", "funded_amnt_inv"}},
+	{Name: "interest_rate", Validator: hasValidInterestRate, Default: true, Columns: []string{"int_rate"}},
+	{Name: "term", Validator: hasValidTerm, Default: true, Columns: []string{"term"}},
+	{Name: "employment_info", Validator: hasEmploymentInfo, Default: true, Columns: []string{"emp_title", "emp_length"}},
+	{Name: "credit_history", Validator: hasEstablishedCreditHistory, Default: true, Columns: []string{"earliest_cr_line"}},
+	{Name: "fico_score", Validator: hasHealthyFICOScore, Default: true, Columns: []string{"fico_range_low", "fico_range_high"}},
+	{Name: "sufficient_accounts", Validator: hasSufficientAccounts, Default: true, Columns: []string{"open_acc", "total_acc"}},
+	{Name: "verified_income", Validator: isVerifiedWithIncome, Default: true, Columns: []string{"annual_inc", "verification_status"}},
+	{Name: "grade_subgrade", Validator: hasValidGradeSubgrade, Default: true, Columns: []string{"grade", "sub_grade"}},
+	{Name: "low_dti", Validator: hasLowDTI, Default: true, Columns: []string{"dti", "home_ownership"}},
+	{Name: "public_records", Validator: hasNoPublicRecordOrBankruptcies, Default: false, Columns: []string{"pub_rec", "pub_rec_bankruptcies", "tax_liens"}},
+	{Name: "joint_income_dti", Validator: hasValidJointIncomeAndDTI, Default: true, Columns: []string{"annual_inc_joint", "dti_joint", "verification_status_joint"}},
+	{Name: "joint_fico_score", Validator: hasHealthySecondaryFICOScore, Default: true, Columns: []string{"sec_app_fico_range_low", "sec_app_fico_range_high"}},
+	{Name: "joint_credit_history", Validator: hasEstablishedSecondaryCreditHistory, Default: true, Columns: []string{"sec_app_earliest_cr_line"}},
+	{Name: "installment_amortization", Validator: hasConsistentInstallment, Default: true, Columns: []string{"installment", "loan_amnt", "int_rate", "term"}},
+	{Name: "grade_rate_band", Validator: hasConsistentGradeRate, Default: true, Columns: []string{"sub_grade", "int_rate"}},
+	{Name: "funded_within_loan", Validator: hasFundedWithinLoanAmount, Default: true, Columns: []string{"funded_amnt", "loan_amnt"}},
+	{Name: "fico_range_width", Validator: hasConsistentFICORange, Default: true, Columns: []string{"fico_range_low", "fico_range_high"}},
+	{Name: "loan_status", Validator: hasKnownLoanStatus, Default: true, Columns: []string{"loan_status"}},
+	{Name: "payment_history", Validator: hasConsistentPaymentHistory, Default: true, Columns: []string{"total_pymnt", "total_rec_prncp", "total_rec_int", "total_rec_late_fee", "recoveries", "collection_recovery_fee", "out_prncp", "last_pymnt_d", "funded_amnt"}},
+	{Name: "address_state", Validator: geo.ValidateState, Default: true, Columns: []string{"addr_state"}},
+	{Name: "zip_prefix", Validator: geo.ValidateZipCode, Default: true, Columns: []string{"zip_code"}},
+	{Name: "hardship", Validator: hardship.ValidateHardship, Default: true, Columns: []string{"hardship_flag", "hardship_type", "hardship_reason", "hardship_status", "hardship_amount", "hardship_start_date", "hardship_end_date", "hardship_length", "hardship_dpd", "hardship_loan_status", "hardship_payoff_balance_amount", "hardship_last_payment_amount"}},
+	{Name: "debt_settlement", Validator: hardship.ValidateSettlement, Default: true, Columns: []string{"debt_settlement_flag", "debt_settlement_flag_date", "settlement_status", "settlement_date", "settlement_amount", "settlement_percentage", "settlement_term"}},
+}
+
+// derivations compute extra fields for every row that passes validation, in this order.
+var derivations = []validator.Derivation{
+	deriveLoanToIncome,
+	deriveCreditHistoryMonths,
+	deriveFICOMidpoint,
+	deriveInstallmentBurden,
+}
+
+// DuplicateKeys are the columns checked for repeats when a DuplicatePolicy is configured:
+// the loan id and member_id, which is skipped when the export leaves it blank.
+var DuplicateKeys = []validator.DuplicateKey{
+	{Name: "id", Column: 0},
+	{Name: "member_id", Column: 1},
+}
+
+// Rules returns every selectable rule, including the ones disabled by default.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// RuleColumns returns the columns each rule checks, by rule name.
+func RuleColumns() map[string][]string {
+	columns := make(map[string][]string, len(rules))
+	for _, r := range rules {
+		columns[r.Name] = r.Columns
+	}
+	return columns
+}
+
+// ActiveRules returns the names of the rules that run with conf, in execution order.
+func ActiveRules(conf *config.ParserConfig) ([]string, error) {
+	active, err := selectRules(conf)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(active))
+	for i, r := range active {
+		names[i] = r.Name
+	}
+	return names, nil
+}
+
+func selectRules(conf *config.ParserConfig) ([]Rule, error) {
+	known := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		known[r.Name] = true
+	}
+	state := make(map[string]bool)
+	for _, name := range conf.EnabledRules {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q in EnabledRules", name)
+		}
+		state[name] = true
+	}
+	for _, name := range conf.DisabledRules {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q in DisabledRules", name)
+		}
+		if state[name] {
+			return nil, fmt.Errorf("rule %q is both enabled and disabled", name)
+		}
+		state[name] = false
+	}
+
+	active := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		enabled, set := state[r.Name]
+		if !set {
+			enabled = r.Default
+		}
+		if enabled {
+			active = append(active, r)
+		}
+	}
+	return active, nil
+}
+
+// buildValidators returns the row size check and the validators of the active rules, followed by the extra data pass-through.
+// The size check runs before the rules, which index columns up to the export's full width.
+func buildValidators(conf *config.ParserConfig) (validator.ColValidator, []validator.ColValidator, error) {
+	// Configs that were not loaded from a file have not been checked yet
+	if err := conf.CheckAsOfDate(); err != nil {
+		return nil, nil, err
+	}
+	active, err := selectRules(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Failures are named by rule so they can be counted per rule
+	validators := make([]validator.ColValidator, 0, len(active)+1)
+	for _, r := range active {
+		validators = append(validators, validator.NamedRule(r.Name, r.Validator))
+	}
+	validators = append(validators, passExtraData)
+	return validator.NamedRule("row_size", isValidSize), validators, nil
+}
+
+// NewRowValidatorPool returns poolSize validators for the active rules.
+// Every valid row is added to aggregateRules after the derived fields are computed.
+func NewRowValidatorPool(conf *config.ParserConfig, cacheChan chan validator.CacheData, poolSize int, aggregateRules ...validator.AggregateRule) (chan validator.CsvRowValidator, error) {
+	sizeCheck, validators, err := buildValidators(conf)
+	if err != nil {
+		return nil, err
+	}
+	rowDerivations := derivations
+	if len(aggregateRules) > 0 {
+		rowDerivations = append(slices.Clip(derivations), validator.Accumulate(aggregateRules))
+	}
 	pool := make(chan validator.CsvRowValidator, poolSize)
 	for i := 0; i < poolSize; i++ {
-		pool <- validator.New(conf, cacheChan, validators)
+		pool <- validator.NewChecked(conf, cacheChan, sizeCheck, validators, rowDerivations...)
 	}
-	return pool
+	return pool, nil
 }
 
 // CloseValidatorPool closes all validators in the pool to prevent resource leaks.
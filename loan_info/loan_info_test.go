@@ -0,0 +1,176 @@
+package loan_info
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ruleNames returns the registered rule names matching keep, in registration order.
+func ruleNames(keep func(Rule) bool) []string {
+	var names []string
+	for _, r := range rules {
+		if keep(r) {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func TestActiveRules(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     config.ParserConfig
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			conf:     config.ParserConfig{},
+			expected: ruleNames(func(r Rule) bool { return r.Default }),
+		},
+		{
+			name: "enable rule that is off by default",
+			conf: config.ParserConfig{EnabledRules: []string{"public_records"}},
+			expected: ruleNames(func(r Rule) bool {
+				return r.Default || r.Name == "public_records"
+			}),
+		},
+		{
+			name: "disable default rules",
+			conf: config.ParserConfig{DisabledRules: []string{"loan_amount", "low_dti"}},
+			expected: ruleNames(func(r Rule) bool {
+				return r.Default && r.Name != "loan_amount" && r.Name != "low_dti"
+			}),
+		},
+		{
+			name:    "unknown enabled rule",
+			conf:    config.ParserConfig{EnabledRules: []string{"no_such_rule"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown disabled rule",
+			conf:    config.ParserConfig{DisabledRules: []string{"no_such_rule"}},
+			wantErr: true,
+		},
+		{
+			name: "rule both enabled and disabled",
+			conf: config.ParserConfig{
+				EnabledRules:  []string{"term"},
+				DisabledRules: []string{"term"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := ActiveRules(&tc.conf)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestActiveRules_OverridesFromFlags(t *testing.T) {
+	conf := config.ParserConfig{DisabledRules: []string{"term"}}
+	// Later calls win, as they do when flags are applied on top of config.json
+	conf.EnableRules("term", "public_records")
+	conf.DisableRules("loan_amount")
+
+	names, err := ActiveRules(&conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	has := func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("term") || !has("public_records") {
+		t.Errorf("expected term and public_records to be active, got %v", names)
+	}
+	if has("loan_amount") {
+		t.Errorf("expected loan_amount to be disabled, got %v", names)
+	}
+}
+
+func TestBuildValidators_WrapsActiveRules(t *testing.T) {
+	conf := config.ParserConfig{EnabledRules: []string{"public_records"}}
+	sizeCheck, validators, err := buildValidators(&conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sizeCheck == nil {
+		t.Errorf("expected a row size check")
+	}
+	// Extra data pass-through last
+	if len(validators) != len(rules)+1 {
+		t.Errorf("expected %d validators, got %d", len(rules)+1, len(validators))
+	}
+}
+
+func TestNewRowValidatorPool_ShortRow(t *testing.T) {
+	conf := config.ParserConfig{Delimiter: ",", ExpectedColumns: 151}
+	cacheChan := make(chan validator.CacheData, 1)
+	pool, err := NewRowValidatorPool(&conf, cacheChan, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer CloseValidatorPool(pool)
+	v := <-pool
+	defer func() { pool <- v }()
+
+	// Rules such as hardship read columns up to 150, so they must not see a short row
+	cols := make([]string, 100)
+	cols[0] = "1"
+	_, err = v.Validate(strings.Join(cols, ","))
+	if validator.ErrorCode(err) != "row_size" {
+		t.Errorf("expected the row size error, got %v", err)
+	}
+}
+
+func TestNewRowValidatorPool_InvalidAsOfDate(t *testing.T) {
+	conf := config.ParserConfig{Delimiter: ",", AsOfDate: "last year"}
+	if _, err := NewRowValidatorPool(&conf, make(chan validator.CacheData, 1), 1); err == nil {
+		t.Errorf("expected an invalid AsOfDate to fail before any row is validated")
+	}
+}
+
+func TestRuleColumns_InHeader(t *testing.T) {
+	data, err := os.ReadFile("../sample.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	headerLine, _, _ := strings.Cut(string(data), "\n")
+	header := make(map[string]bool)
+	for _, name := range strings.Split(headerLine, ",") {
+		header[name] = true
+	}
+	for rule, columns := range RuleColumns() {
+		if len(columns) == 0 {
+			t.Errorf("rule %s lists no columns", rule)
+		}
+		for _, column := range columns {
+			if !header[column] {
+				t.Errorf("rule %s lists %s, which is not in the header", rule, column)
+			}
+		}
+	}
+}
@@ -32,35 +32,32 @@ const (
 	colSubgrade      = 9
 )
 
-func convertAmtToInt(amt string) (int, error) {
-	tmpStr := utils.TrimIfNeeded(amt)
-	if strings.HasSuffix(tmpStr, ".0") {
-		tmpStr = tmpStr[:len(tmpStr)-2]
-	}
-	return strconv.Atoi(tmpStr)
-}
+var (
+	minInterestRate = utils.NewDecimal(5)
+	maxInterestRate = utils.NewDecimal(35)
+)
 
 func hasValidLoanAmount(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
 
-	loanAmount, err := convertAmtToInt(cols[colLoanAmount])
+	loanAmount, err := parseDecimalColumn(vCtx, "loan_amnt", cols[colLoanAmount])
 	if err != nil {
 		return nil, ErrLoanAmountNotNumber
 	}
-	if loanAmount <= 0 {
+	if loanAmount.Sign() <= 0 {
 		return nil, ErrLoanAmountNotPositive
 	}
-	fundingAmount, err := convertAmtToInt(cols[colFundingAmount])
+	fundingAmount, err := parseDecimalColumn(vCtx, "funded_amnt", cols[colFundingAmount])
 	if err != nil {
 		return nil, ErrFundingAmountNotNumber
 	}
-	if fundingAmount <= 0 {
+	if fundingAmount.Sign() <= 0 {
 		return nil, ErrFundingAmountNotPositive
 	}
-	fundingInvAmt, err := convertAmtToInt(cols[colFundingInvAmt])
+	fundingInvAmt, err := parseDecimalColumn(vCtx, "funded_amnt_inv", cols[colFundingInvAmt])
 	if err != nil {
 		return nil, ErrFundingInvAmtNotNumber
 	}
-	if fundingInvAmt <= 0 {
+	if fundingInvAmt.Sign() <= 0 {
 		return nil, ErrFundingInvAmtNotPositive
 	}
 	if fundingInvAmt != fundingAmount {
@@ -69,32 +66,33 @@ func hasValidLoanAmount(vCtx *validator.RowValidatorContext, cols []string) (map
 
 	// Get a map from the pool
 	result := vCtx.GetMap()
-	result["loanAmount"] = cols[colLoanAmount]
-	result["fundingAmount"] = cols[colFundingAmount]
-	result["fundingInvAmt"] = cols[colFundingInvAmt]
+	result["loanAmount"] = loanAmount.String()
+	result["fundingAmount"] = fundingAmount.String()
+	result["fundingInvAmt"] = fundingInvAmt.String()
 
 	return result, nil
 }
 
 func hasValidInterestRate(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
-	rate, err := strconv.ParseFloat(cols[6], 64)
+	rate, err := parseDecimalColumn(vCtx, "int_rate", cols[colInterestRate])
 	if err != nil {
 		return nil, ErrInterestRateNotNumber
 	}
-	if rate < 5 || rate > 35 {
+	if rate.Cmp(minInterestRate) < 0 || rate.Cmp(maxInterestRate) > 0 {
 		return nil, ErrInterestRateOutOfRange
 	}
 
 	// Get a map from the pool
 	result := vCtx.GetMap()
-	result["interestRate"] = cols[6]
+	result["interestRate"] = rate.String()
 
 	return result, nil
 }
 
-func hasValidTerm(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+// parseTermMonths parses a term such as " 36 months" into a number of months.
+func parseTermMonths(s string) (int, error) {
 	// First trim spaces from the original string
-	termStr := utils.TrimIfNeeded(cols[5])
+	termStr := utils.TrimIfNeeded(s)
 
 	// Remove the " months" suffix, handling case where there might be spaces
 	// Use strings.HasSuffix to check if the string ends with " months"
@@ -107,9 +105,11 @@ func hasValidTerm(vCtx *validator.RowValidatorContext, cols []string) (map[strin
 	}
 
 	// Trim spaces again
-	termStr = utils.TrimIfNeeded(termStr)
-	term, err := strconv.Atoi(termStr)
+	return strconv.Atoi(utils.TrimIfNeeded(termStr))
+}
 
+func hasValidTerm(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	term, err := parseTermMonths(cols[colTerm])
 	if err != nil {
 		return nil, ErrTermNotNumber
 	}
@@ -1,7 +1,9 @@
 package loan_info
 
 import (
+	"cmp"
 	"go-file-parsing/config"
+	"go-file-parsing/utils"
 	"go-file-parsing/validator"
 	"strconv"
 	"strings"
@@ -14,12 +16,38 @@ func TestHasValidLoanAmount(t *testing.T) {
 		cols    []string
 		wantErr bool
 		errMsg  string
+		// cached are the canonical loanAmount, fundingAmount and fundingInvAmt, when they differ from cols[2:5]
+		cached []string
 	}{
 		{
 			name:    "valid loan amounts",
 			cols:    []string{"id", "name", "1000", "500", "500"},
 			wantErr: false,
 		},
+		{
+			name:    "valid loan amounts with cents",
+			cols:    []string{"id", "name", "1000.50", "500.25", "500.25"},
+			wantErr: false,
+			cached:  []string{"1000.5", "500.25", "500.25"},
+		},
+		{
+			name:    "valid loan amounts in dataset format",
+			cols:    []string{"id", "name", "3600.0", "3600.0", "3600.0"},
+			wantErr: false,
+			cached:  []string{"3600", "3600", "3600"},
+		},
+		{
+			name:    "valid loan amounts with currency and grouping",
+			cols:    []string{"id", "name", "$1,000.50", "1,000.50", "1,000.50"},
+			wantErr: false,
+			cached:  []string{"1000.5", "1000.5", "1000.5"},
+		},
+		{
+			name:    "funding inv amount differs by a cent",
+			cols:    []string{"id", "name", "1000", "500.00", "500.01"},
+			wantErr: true,
+			errMsg:  "funding inv amt is not equal to funding amount",
+		},
 		{
 			name:    "non-numeric loan amount",
 			cols:    []string{"id", "name", "abc", "500", "500"},
@@ -109,15 +137,15 @@ func TestHasValidLoanAmount(t *testing.T) {
 				return
 			}
 
-			// Verify the returned map contains the expected values
-			if result["loanAmount"] != tc.cols[2] {
-				t.Errorf("expected loanAmount '%s', got '%s'", tc.cols[2], result["loanAmount"])
-			}
-			if result["fundingAmount"] != tc.cols[3] {
-				t.Errorf("expected fundingAmount '%s', got '%s'", tc.cols[3], result["fundingAmount"])
+			// Verify the returned map contains the canonical values
+			cached := tc.cached
+			if cached == nil {
+				cached = tc.cols[2:5]
 			}
-			if result["fundingInvAmt"] != tc.cols[4] {
-				t.Errorf("expected fundingInvAmt '%s', got '%s'", tc.cols[4], result["fundingInvAmt"])
+			for i, key := range []string{"loanAmount", "fundingAmount", "fundingInvAmt"} {
+				if result[key] != cached[i] {
+					t.Errorf("expected %s '%s', got '%s'", key, cached[i], result[key])
+				}
 			}
 		})
 	}
@@ -129,6 +157,8 @@ func TestHasValidInterestRate(t *testing.T) {
 		cols    []string
 		wantErr bool
 		errMsg  string
+		// cached is the canonical interestRate, when it differs from cols[6]
+		cached string
 	}{
 		{
 			name:    "valid interest rate",
@@ -139,12 +169,30 @@ func TestHasValidInterestRate(t *testing.T) {
 			name:    "valid interest rate at minimum",
 			cols:    []string{"id", "name", "1000", "500", "500", "term", "05"},
 			wantErr: false,
+			cached:  "5",
 		},
 		{
 			name:    "valid interest rate at maximum",
 			cols:    []string{"id", "name", "1000", "500", "500", "term", "35"},
 			wantErr: false,
 		},
+		{
+			name:    "valid interest rate with decimals",
+			cols:    []string{"id", "name", "1000", "500", "500", "term", "13.99"},
+			wantErr: false,
+		},
+		{
+			name:    "valid interest rate with percent sign",
+			cols:    []string{"id", "name", "1000", "500", "500", "term", " 13.56%"},
+			wantErr: false,
+			cached:  "13.56",
+		},
+		{
+			name:    "interest rate just above maximum",
+			cols:    []string{"id", "name", "1000", "500", "500", "term", "35.01"},
+			wantErr: true,
+			errMsg:  "interest rate is not between 5% and 35%",
+		},
 		{
 			name:    "non-numeric interest rate",
 			cols:    []string{"id", "name", "1000", "500", "500", "term", "abc"},
@@ -193,8 +241,8 @@ func TestHasValidInterestRate(t *testing.T) {
 			}
 
 			// Verify the returned map contains the expected values
-			if result["interestRate"] != tc.cols[6] {
-				t.Errorf("expected interestRate '%s', got '%s'", tc.cols[6], result["interestRate"])
+			if cached := cmp.Or(tc.cached, tc.cols[6]); result["interestRate"] != cached {
+				t.Errorf("expected interestRate '%s', got '%s'", cached, result["interestRate"])
 			}
 		})
 	}
@@ -426,3 +474,24 @@ func TestHasValidGradeSubgrade(t *testing.T) {
 		})
 	}
 }
+
+func TestHasValidInterestRate_ConfiguredNumberFormat(t *testing.T) {
+	ctx := &validator.RowValidatorContext{
+		Config: &config.ParserConfig{
+			NumberFormats: map[string]utils.NumberFormat{
+				"int_rate": {PercentSuffix: true, DecimalComma: true},
+			},
+		},
+		GetMap: mockGetMap,
+	}
+
+	if _, err := hasValidInterestRate(ctx, []string{"id", "name", "1000", "500", "500", "term", "13,56%"}); err != nil {
+		t.Errorf("unexpected error for decimal comma rate: %v", err)
+	}
+
+	// The configured format replaces the default one, so a decimal point is no longer accepted
+	_, err := hasValidInterestRate(ctx, []string{"id", "name", "1000", "500", "500", "term", "13.56%"})
+	if err == nil || err.Error() != "interest rate is not a number" {
+		t.Errorf("expected interest rate is not a number, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package loan_info
+
+import (
+	"go-file-parsing/loan_info/formats"
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+)
+
+// defaultNumberFormats describes how the Lending Club export writes its numeric columns.
+// Entries in ParserConfig.NumberFormats replace these per column.
+var defaultNumberFormats = map[string]utils.NumberFormat{
+	"loan_amnt":        formats.Amount,
+	"funded_amnt":      formats.Amount,
+	"funded_amnt_inv":  formats.Amount,
+	"int_rate":         formats.Percent,
+	"installment":      formats.Amount,
+	"annual_inc":       formats.Amount,
+	"dti":              formats.Percent,
+	"annual_inc_joint": formats.Amount,
+	"dti_joint":        formats.Percent,
+	"revol_util":       formats.Percent,
+}
+
+// parseDecimalColumn normalizes a column value with its number format and parses it.
+// Rules cache the canonical form of the parsed value (utils.Decimal.String), not the text as written, so every decimal
+// field of a record reads the same way: " 13.56%" is cached as "13.56", "$45,000" as "45000" and "18.70" as "18.7".
+func parseDecimalColumn(vCtx *validator.RowValidatorContext, column, value string) (utils.Decimal, error) {
+	return formats.ParseDecimal(vCtx, column, defaultNumberFormats[column], value)
+}
@@ -2,38 +2,53 @@ package loan_info
 
 import "go-file-parsing/validator"
 
+// Column index constants for pass-through fields
+const (
+	colAccNowDelinq = 60
+	colTotCollAmt   = 61
+	colAvgCurBal    = 79
+)
+
 func passExtraData(ctx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
 	// Get a map from the pool
 	result := ctx.GetMap()
 
 	// Check if we have enough columns
-	if len(cols) < 93 { // avg_cur_bal is at index 92, which is the highest index we need
+	if len(cols) <= colAvgCurBal { // avg_cur_bal is the highest index we need
 		return result, nil
 	}
 
-	// Add avg_cur_bal (index 92)
-	if cols[92] != "" {
-		result["avg_cur_bal"] = cols[92]
+	// Add avg_cur_bal (index 79)
+	if cols[colAvgCurBal] != "" {
+		result["avg_cur_bal"] = cols[colAvgCurBal]
 	}
 
-	// Add application_type (index 69)
-	if cols[69] != "" {
-		result["application_type"] = cols[69]
+	// Add application_type (index 56)
+	if cols[colApplicationType] != "" {
+		result["application_type"] = cols[colApplicationType]
 
-		// Add annual_inc_joint (index 70) only if application_type is "Joint App"
-		if cols[69] == "Joint App" && cols[70] != "" {
-			result["annual_inc_joint"] = cols[70]
+		// Add annual_inc_joint (index 57) only if application_type is "Joint App"
+		if cols[colApplicationType] == jointApplicationType && cols[colAnnualIncJoint] != "" {
+			result["annual_inc_joint"] = cols[colAnnualIncJoint]
 		}
 	}
 
-	// Add tot_coll_amt (index 74)
-	if cols[74] != "" {
-		result["tot_coll_amt"] = cols[74]
+	// Add tot_coll_amt (index 61)
+	if cols[colTotCollAmt] != "" {
+		result["tot_coll_amt"] = cols[colTotCollAmt]
+	}
+
+	// Add issue_d (index 15) and last_pymnt_d (index 47) in ISO form when they parse
+	if _, isoDate, err := parseDateColumn(ctx, "issue_d", cols[colIssueD]); err == nil {
+		result["issue_d"] = isoDate
+	}
+	if _, isoDate, err := parseDateColumn(ctx, "last_pymnt_d", cols[colLastPymntD]); err == nil {
+		result["last_pymnt_d"] = isoDate
 	}
 
-	// Add acc_now_delinq (index 73)
-	if cols[73] != "" {
-		result["acc_now_delinq"] = cols[73]
+	// Add acc_now_delinq (index 60)
+	if cols[colAccNowDelinq] != "" {
+		result["acc_now_delinq"] = cols[colAccNowDelinq]
 	}
 
 	return result, nil
@@ -15,11 +15,11 @@ func TestPassExtraData(t *testing.T) {
 		{
 			name: "all fields present with Joint App",
 			cols: createColumnsWithValues(map[int]string{
-				69: "Joint App",
-				70: "100000",
-				73: "2",
-				74: "5000",
-				92: "3000",
+				56: "Joint App",
+				57: "100000",
+				60: "2",
+				61: "5000",
+				79: "3000",
 			}),
 			expected: map[string]string{
 				"application_type": "Joint App",
@@ -32,11 +32,11 @@ func TestPassExtraData(t *testing.T) {
 		{
 			name: "all fields present with Individual application",
 			cols: createColumnsWithValues(map[int]string{
-				69: "Individual",
-				70: "100000", // This should not be included in the result
-				73: "0",
-				74: "1000",
-				92: "2500",
+				56: "Individual",
+				57: "100000", // This should not be included in the result
+				60: "0",
+				61: "1000",
+				79: "2500",
 			}),
 			expected: map[string]string{
 				"application_type": "Individual",
@@ -45,11 +45,23 @@ func TestPassExtraData(t *testing.T) {
 				"avg_cur_bal":      "2500",
 			},
 		},
+		{
+			name: "dates are normalized to ISO",
+			cols: createColumnsWithValues(map[int]string{
+				15: "Dec-2015",
+				47: "Jan-2019",
+				79: "",
+			}),
+			expected: map[string]string{
+				"issue_d":      "2015-12",
+				"last_pymnt_d": "2019-01",
+			},
+		},
 		{
 			name: "some fields missing",
 			cols: createColumnsWithValues(map[int]string{
-				69: "Individual",
-				92: "2500",
+				56: "Individual",
+				79: "2500",
 			}),
 			expected: map[string]string{
 				"application_type": "Individual",
@@ -59,11 +71,11 @@ func TestPassExtraData(t *testing.T) {
 		{
 			name: "empty fields",
 			cols: createColumnsWithValues(map[int]string{
-				69: "",
-				70: "",
-				73: "",
-				74: "",
-				92: "",
+				56: "",
+				57: "",
+				60: "",
+				61: "",
+				79: "",
 			}),
 			expected: map[string]string{},
 		},
@@ -0,0 +1,142 @@
+package loan_info
+
+import (
+	"go-file-parsing/utils"
+	"go-file-parsing/validator"
+	"strings"
+)
+
+// Column index constants for loan status and payment fields
+const (
+	colLoanStatus            = 16
+	colOutPrncp              = 38
+	colTotalPymnt            = 40
+	colTotalRecPrncp         = 42
+	colTotalRecInt           = 43
+	colTotalRecLateFee       = 44
+	colRecoveries            = 45
+	colCollectionRecoveryFee = 46
+)
+
+// Loan statuses used by the export
+const (
+	statusFullyPaid  = "Fully Paid"
+	statusChargedOff = "Charged Off"
+	statusCurrent    = "Current"
+	statusInGrace    = "In Grace Period"
+	statusLate16     = "Late (16-30 days)"
+	statusLate31     = "Late (31-120 days)"
+	statusDefault    = "Default"
+)
+
+// creditPolicyPrefix marks loans issued outside the current credit policy, e.g.
+// "Does not meet the credit policy. Status:Fully Paid". The status after the prefix is what is checked.
+const creditPolicyPrefix = "Does not meet the credit policy. Status:"
+
+var knownLoanStatuses = map[string]bool{
+	statusFullyPaid:  true,
+	statusChargedOff: true,
+	statusCurrent:    true,
+	statusInGrace:    true,
+	statusLate16:     true,
+	statusLate31:     true,
+	statusDefault:    true,
+}
+
+// paymentTolerance absorbs cent rounding between the payment breakdown columns.
+var paymentTolerance = utils.NewDecimal(1)
+
+// loanStatus returns the row's loan status with any credit policy prefix removed.
+func loanStatus(cols []string) string {
+	return strings.TrimPrefix(utils.TrimIfNeeded(cols[colLoanStatus]), creditPolicyPrefix)
+}
+
+// Rule 20: Known Loan Status
+// loan_status is one of the statuses used by the export, optionally with the credit policy prefix.
+func hasKnownLoanStatus(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	status := loanStatus(cols)
+	if !knownLoanStatuses[status] {
+		return nil, ErrLoanStatusUnknown
+	}
+	result := vCtx.GetMap()
+	result["loanStatus"] = utils.TrimIfNeeded(cols[colLoanStatus])
+	return result, nil
+}
+
+// Rule 21: Payment History Matches Loan Status
+// total_pymnt equals the sum of principal, interest, late fees and recoveries; and by status:
+// Fully Paid has out_prncp == 0, total_rec_prncp ≈ funded_amnt and a last payment date;
+// Charged Off has recoveries and collection_recovery_fee populated;
+// Current, In Grace Period and Late loans have out_prncp > 0.
+func hasConsistentPaymentHistory(vCtx *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	outPrncp, err := parseDecimalColumn(vCtx, "out_prncp", cols[colOutPrncp])
+	if err != nil {
+		return nil, ErrOutPrncpNotNumber
+	}
+	totalPymnt, err := parseDecimalColumn(vCtx, "total_pymnt", cols[colTotalPymnt])
+	if err != nil {
+		return nil, ErrTotalPymntNotNumber
+	}
+	totalRecPrncp, err := parseDecimalColumn(vCtx, "total_rec_prncp", cols[colTotalRecPrncp])
+	if err != nil {
+		return nil, ErrTotalRecPrncpNotNumber
+	}
+	totalRecInt, err := parseDecimalColumn(vCtx, "total_rec_int", cols[colTotalRecInt])
+	if err != nil {
+		return nil, ErrTotalRecIntNotNumber
+	}
+	totalRecLateFee, err := parseDecimalColumn(vCtx, "total_rec_late_fee", cols[colTotalRecLateFee])
+	if err != nil {
+		return nil, ErrTotalRecLateFeeNotNumber
+	}
+
+	status := loanStatus(cols)
+	recoveriesStr := utils.TrimIfNeeded(cols[colRecoveries])
+	feeStr := utils.TrimIfNeeded(cols[colCollectionRecoveryFee])
+	if status == statusChargedOff && (recoveriesStr == "" || feeStr == "") {
+		return nil, ErrRecoveriesMissing
+	}
+	var recoveries utils.Decimal
+	if recoveriesStr != "" {
+		recoveries, err = parseDecimalColumn(vCtx, "recoveries", recoveriesStr)
+		if err != nil {
+			return nil, ErrRecoveriesNotNumber
+		}
+	}
+	if feeStr != "" {
+		if _, err = parseDecimalColumn(vCtx, "collection_recovery_fee", feeStr); err != nil {
+			return nil, ErrRecoveriesNotNumber
+		}
+	}
+
+	if (totalPymnt - (totalRecPrncp + totalRecInt + totalRecLateFee + recoveries)).Abs().Cmp(paymentTolerance) > 0 {
+		return nil, ErrTotalPymntMismatch
+	}
+
+	switch status {
+	case statusFullyPaid:
+		if outPrncp.Sign() != 0 {
+			return nil, ErrFullyPaidOutstandingPrincipal
+		}
+		fundedAmount, err := parseDecimalColumn(vCtx, "funded_amnt", cols[colFundingAmount])
+		if err != nil {
+			return nil, ErrFundingAmountNotNumber
+		}
+		if (totalRecPrncp - fundedAmount).Abs().Cmp(paymentTolerance) > 0 {
+			return nil, ErrFullyPaidPrincipalMismatch
+		}
+		if utils.TrimIfNeeded(cols[colLastPymntD]) == "" {
+			return nil, ErrFullyPaidNoLastPayment
+		}
+	case statusCurrent, statusInGrace, statusLate16, statusLate31:
+		if outPrncp.Sign() <= 0 {
+			return nil, ErrActiveLoanNoOutstandingPrincipal
+		}
+	}
+
+	result := vCtx.GetMap()
+	result["outPrncp"] = outPrncp.String()
+	result["totalPymnt"] = totalPymnt.String()
+	result["totalRecPrncp"] = totalRecPrncp.String()
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package loan_info
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"testing"
+)
+
+// paymentCols builds a row with the payment columns set; overrides replace individual values.
+func paymentCols(status string, overrides map[int]string) []string {
+	values := map[int]string{
+		3:  "3600.0",
+		16: status,
+		38: "0.0",
+		40: "4421.723916800001",
+		42: "3600.0",
+		43: "821.72",
+		44: "0.0",
+		45: "0.0",
+		46: "0.0",
+		47: "Jan-2019",
+	}
+	for idx, val := range overrides {
+		values[idx] = val
+	}
+	return createColumnsWithValues(values)
+}
+
+func TestHasKnownLoanStatus(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  string
+		wantErr bool
+	}{
+		{name: "fully paid", status: "Fully Paid"},
+		{name: "charged off", status: "Charged Off"},
+		{name: "late", status: "Late (31-120 days)"},
+		{name: "credit policy prefix", status: "Does not meet the credit policy. Status:Fully Paid"},
+		{name: "unknown status", status: "Paid-ish", wantErr: true},
+		{name: "empty status", status: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasKnownLoanStatus(ctx, createTestCols(16, tc.status))
+			if tc.wantErr {
+				if err == nil || err.Error() != "loan status is not a known status" {
+					t.Errorf("expected unknown status error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["loanStatus"] != tc.status {
+				t.Errorf("expected loanStatus '%s', got '%s'", tc.status, result["loanStatus"])
+			}
+		})
+	}
+}
+
+func TestHasConsistentPaymentHistory(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cols   []string
+		errMsg string
+	}{
+		{
+			name: "fully paid dataset row",
+			cols: paymentCols("Fully Paid", nil),
+		},
+		{
+			name: "fully paid under old credit policy",
+			cols: paymentCols("Does not meet the credit policy. Status:Fully Paid", nil),
+		},
+		{
+			name:   "fully paid with outstanding principal",
+			cols:   paymentCols("Fully Paid", map[int]string{38: "120.50"}),
+			errMsg: "loan is Fully Paid but outstanding principal is not zero",
+		},
+		{
+			name:   "fully paid with principal short of funded amount",
+			cols:   paymentCols("Fully Paid", map[int]string{40: "4221.72", 42: "3400.0"}),
+			errMsg: "loan is Fully Paid but received principal does not match funded amount",
+		},
+		{
+			name:   "fully paid without last payment date",
+			cols:   paymentCols("Fully Paid", map[int]string{47: ""}),
+			errMsg: "loan is Fully Paid but has no last payment date",
+		},
+		{
+			name: "charged off with recoveries",
+			cols: paymentCols("Charged Off", map[int]string{40: "1500.00", 42: "900.00", 43: "400.00", 45: "200.00", 46: "36.00"}),
+		},
+		{
+			name:   "charged off without recoveries",
+			cols:   paymentCols("Charged Off", map[int]string{40: "1300.00", 42: "900.00", 43: "400.00", 45: "", 46: ""}),
+			errMsg: "loan is Charged Off but recoveries are not populated",
+		},
+		{
+			name: "current loan with balance",
+			cols: paymentCols("Current", map[int]string{38: "2500.00", 40: "1500.00", 42: "1100.00", 43: "400.00"}),
+		},
+		{
+			name:   "current loan without balance",
+			cols:   paymentCols("Current", map[int]string{40: "1500.00", 42: "1100.00", 43: "400.00"}),
+			errMsg: "loan is active but outstanding principal is not positive",
+		},
+		{
+			name:   "payment breakdown does not add up",
+			cols:   paymentCols("Fully Paid", map[int]string{40: "5000.00"}),
+			errMsg: "total payment does not equal principal, interest, late fees and recoveries received",
+		},
+		{
+			name:   "outstanding principal not a number",
+			cols:   paymentCols("Fully Paid", map[int]string{38: ""}),
+			errMsg: "outstanding principal is not a number",
+		},
+		{
+			name:   "total payment not a number",
+			cols:   paymentCols("Fully Paid", map[int]string{40: "abc"}),
+			errMsg: "total payment is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &validator.RowValidatorContext{
+				Config: &config.ParserConfig{},
+				GetMap: mockGetMap,
+			}
+
+			result, err := hasConsistentPaymentHistory(ctx, tc.cols)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result["totalRecPrncp"] == "" || result["totalPymnt"] == "" || result["outPrncp"] == "" {
+				t.Errorf("expected payment fields to be cached, got %v", result)
+			}
+		})
+	}
+}
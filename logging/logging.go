@@ -0,0 +1,30 @@
+// Package logging builds the structured logger of a run.
+package logging
+
+import (
+	"go-file-parsing/config"
+	"io"
+	"log/slog"
+)
+
+// Keys of the attributes every record of a run carries
+const (
+	RunIDKey = "run_id"
+	FileKey  = "file"
+)
+
+// New returns a logger that writes records in lc's format and at lc's level to w, each carrying runID and filename.
+func New(w io.Writer, lc config.LoggingConfig, runID, filename string) (*slog.Logger, error) {
+	level, err := lc.SlogLevel()
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if lc.Format == config.LogJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(handler).With(RunIDKey, runID, FileKey, filename), nil
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-file-parsing/config"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		lc        config.LoggingConfig
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{name: "default", lc: config.LoggingConfig{}, wantInfo: true},
+		{name: "debug", lc: config.LoggingConfig{Level: "debug"}, wantDebug: true, wantInfo: true},
+		{name: "warn", lc: config.LoggingConfig{Level: "WARN"}},
+		{name: "rows lowers the level", lc: config.LoggingConfig{Level: "warn", Rows: true}, wantDebug: true, wantInfo: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			logger, err := New(&b, tt.lc, "run-1", "loans.csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			logger.Debug("debug record")
+			logger.Info("info record")
+			if got := strings.Contains(b.String(), "debug record"); got != tt.wantDebug {
+				t.Errorf("expected debug logged %v, got %v", tt.wantDebug, got)
+			}
+			if got := strings.Contains(b.String(), "info record"); got != tt.wantInfo {
+				t.Errorf("expected info logged %v, got %v", tt.wantInfo, got)
+			}
+		})
+	}
+}
+
+func TestNew_JSON(t *testing.T) {
+	var b bytes.Buffer
+	logger, err := New(&b, config.LoggingConfig{Format: config.LogJSON}, "run-1", "loans.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("Processed rows", "rows", 10000)
+
+	var record map[string]any
+	if err = json.Unmarshal(b.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %s: %v", b.String(), err)
+	}
+	if record[RunIDKey] != "run-1" || record[FileKey] != "loans.csv" || record["rows"] != float64(10000) {
+		t.Errorf("expected the run id, file and rows in the record, got %s", b.String())
+	}
+}
+
+func TestNew_InvalidLevel(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, config.LoggingConfig{Level: "verbose"}, "run-1", "loans.csv"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
@@ -1,165 +1,526 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"go-file-parsing/cache"
+	"go-file-parsing/charset"
 	"go-file-parsing/config"
+	"go-file-parsing/dialect"
 	"go-file-parsing/loan_info"
+	"go-file-parsing/logging"
+	"go-file-parsing/manifest"
+	"go-file-parsing/metrics"
+	"go-file-parsing/pipeline"
+	"go-file-parsing/profile"
+	"go-file-parsing/report"
+	"go-file-parsing/schema"
 	"go-file-parsing/validator"
-	"log"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"runtime"
-	"strconv"
-	"sync"
+	"strings"
 	"time"
 )
 
-const (
-	cachePoolSize = 10000
-	errPoolSize   = 10000
-	rowPoolSize   = 1000
-)
-
 func main() {
-	cacheClient, err := cache.New()
+	configFile := flag.String("config", "config.json", "path to the parser configuration")
+	enableRules := flag.String("enable-rules", "", "comma-separated rule names to enable for this run")
+	disableRules := flag.String("disable-rules", "", "comma-separated rule names to disable for this run")
+	duplicatePolicy := flag.String("duplicate-policy", "", "how to handle repeated loan ids: reject, keep-first or keep-last")
+	manifestFile := flag.String("manifest", "", "optional control file with the expected rows, bytes and sha256 of the input")
+	profileFormat := flag.String("profile", "", "profile the columns instead of validating, writing a json or markdown report")
+	profileOut := flag.String("profile-out", "", "file for the profile report (default stdout)")
+	inferSchemaOut := flag.String("infer-schema", "", "sample the file and write a starter config with the inferred column schema to this path")
+	sampleRows := flag.Int("sample-rows", 10000, "number of data rows sampled by -infer-schema")
+	dialectMode := flag.String("dialect", "", "sniff the file before processing: check reports config mismatches, auto applies the detected dialect")
+	encoding := flag.String("encoding", "", "character encoding of the file: utf-8, latin-1, windows-1252, utf-16le, utf-16be or auto")
+	autotune := flag.Bool("autotune", false, "adjust the number of validate and write workers while running and log the sizes chosen")
+	metricsAddr := flag.String("metrics-addr", "", "serve Prometheus metrics at /metrics on this address while the file is processed, e.g. :9090")
+	reportFile := flag.String("report", "", "also write the JSON run report to this file; it is always stored in the cache under report:<run id>")
+	runID := flag.String("run-id", "", "id of the run in its report and logs (default the file name, start time and a random suffix)")
+	logFormat := flag.String("log-format", "", "log record format: text or json")
+	logLevel := flag.String("log-level", "", "lowest level logged: debug, info, warn or error")
+	logRows := flag.Bool("log-rows", false, "log every row validated or rejected at debug level; implies -log-level=debug")
+	flag.Parse()
+
+	conf, err := config.LoadParserConfig(*configFile)
 	if err != nil {
 		panic(err)
 	}
-	defer cacheClient.Close()
-	start := time.Now()
+	// Flags take precedence over the rule lists in the config file
+	if names := splitNames(*enableRules); len(names) > 0 {
+		conf.EnableRules(names...)
+	}
+	if names := splitNames(*disableRules); len(names) > 0 {
+		conf.DisableRules(names...)
+	}
+	if *duplicatePolicy != "" {
+		conf.DuplicatePolicy = *duplicatePolicy
+		if err = conf.CheckDuplicatePolicy(); err != nil {
+			panic(err)
+		}
+	}
+	if *encoding != "" {
+		conf.Encoding = *encoding
+		if err = conf.CheckEncoding(); err != nil {
+			panic(err)
+		}
+	}
+	if *autotune {
+		conf.Pipeline.Autotune = true
+	}
+	if *dialectMode != "" {
+		conf.DialectDetection = *dialectMode
+		if err = conf.CheckDialectDetection(); err != nil {
+			panic(err)
+		}
+	}
+	if *logFormat != "" {
+		conf.Logging.Format = *logFormat
+	}
+	if *logLevel != "" {
+		conf.Logging.Level = *logLevel
+	}
+	if *logRows {
+		conf.Logging.Rows = true
+	}
+	if err = conf.CheckLogging(); err != nil {
+		panic(err)
+	}
 
 	// Default file to parse
 	fileToProcess := "data/accepted_2007_to_2018Q4.csv"
+	fromArgs := flag.NArg() > 0
+	if fromArgs {
+		fileToProcess = flag.Arg(0)
+	}
 
-	// Check if a file was specified as a command-line argument
-	if len(os.Args) > 1 {
-		fileToProcess = os.Args[1]
-		log.Printf("Using file specified by command-line argument: %s", fileToProcess)
+	// Every record from here on carries the run id and file
+	if *runID == "" {
+		*runID = report.NewRunID(fileToProcess, time.Now())
+	}
+	logger, err := logging.New(os.Stderr, conf.Logging, *runID, fileToProcess)
+	if err != nil {
+		panic(err)
+	}
+	slog.SetDefault(logger)
+	if fromArgs {
+		slog.Info("Using file specified by command-line argument")
 	} else {
-		log.Printf("No file specified, using default: %s", fileToProcess)
+		slog.Info("No file specified, using default")
+	}
+
+	if conf.DialectDetection != "" && !conf.Delimited() {
+		slog.Info("Skipping dialect detection", "format", conf.Format)
+	} else if conf.DialectDetection != "" {
+		if err = detectDialect(fileToProcess, &conf); err != nil {
+			fatal("Dialect detection failed", err)
+		}
+	}
+
+	// Profiling and schema inference only read the file, so they do not need the cache
+	if *inferSchemaOut != "" {
+		if err = inferSchema(fileToProcess, &conf, *inferSchemaOut, *sampleRows); err != nil {
+			fatal("Schema inference failed", err)
+		}
+		return
+	}
+	if *profileFormat != "" {
+		if err = profileFile(fileToProcess, &conf, *profileFormat, *profileOut); err != nil {
+			fatal("Profiling failed", err)
+		}
+		return
+	}
+
+	cacheClient, err := cache.New()
+	if err != nil {
+		panic(err)
+	}
+	defer cacheClient.Close()
+	var reg *metrics.Registry
+	if *metricsAddr != "" {
+		reg = metrics.NewRegistry()
+		reg.RegisterRuntime()
+		serveMetrics(*metricsAddr, reg)
 	}
+	start := time.Now()
+	run := report.Start(*runID, fileToProcess, &conf, loan_info.RuleColumns(), start)
 
-	parseFile(fileToProcess, cacheClient)
+	runErr := parseFile(fileToProcess, *manifestFile, &conf, cacheClient, reg, run)
 	end := time.Now()
-	log.Printf("Time elapsed: %s", end.Sub(start))
+	slog.Info("Time elapsed", "elapsed", end.Sub(start).String())
+	saveReport(run.Finish(end, runErr), *reportFile, cacheClient)
+	if runErr != nil {
+		cacheClient.Close()
+		fatal("Run failed", runErr)
+	}
 }
 
-func NewErrChan(cache cache.DistributedCache, size int, wg *sync.WaitGroup) chan validator.RowError {
-	errChan := make(chan validator.RowError, size)
-	errWorkerPool := make(chan func(validator.RowError), size)
-	for i := 0; i < size; i++ {
-		errWorkerPool <- func(err validator.RowError) {
-			cacheErr := cache.Set(context.Background(), fmt.Sprintf("err:row%s:id%s", strconv.FormatInt(err.Row, 10), err.Id), err.Error.Error())
-			if cacheErr != nil {
-				log.Printf("Error writing to cache: %v", cacheErr)
-			}
+// splitNames splits a comma-separated flag value into names, trimming spaces and dropping empty entries,
+// so "a, b," is read as a and b.
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
 		}
 	}
-	wg.Add(1)
+	return names
+}
+
+// fatal logs msg with err at error level and exits with status 1.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// saveReport stores rep in the cache and, when filename is set, writes it there too. Failures are logged, not fatal.
+func saveReport(rep report.Report, filename string, cacheClient cache.DistributedCache) {
+	if err := rep.Store(context.Background(), cacheClient); err != nil {
+		slog.Error("Error writing run report to cache", "err", err)
+	} else {
+		slog.Info("Run report stored in the cache", "key", rep.Key())
+	}
+	if filename == "" {
+		return
+	}
+	if err := rep.Write(filename); err != nil {
+		slog.Error("Error writing run report", "report", filename, "err", err)
+		return
+	}
+	slog.Info("Run report written", "report", filename)
+}
+
+// serveMetrics serves reg at /metrics on addr in the background. A server that cannot start is logged, not fatal.
+func serveMetrics(addr string, reg *metrics.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
 	go func() {
-		defer wg.Done()
-		for err := range errChan {
-			worker := <-errWorkerPool
-			go func(e validator.RowError) {
-				worker(e)
-				errWorkerPool <- worker
-			}(err)
+		if err := server.ListenAndServe(); err != nil {
+			slog.Error("Metrics server stopped", "err", err)
 		}
 	}()
-	return errChan
+	slog.Info("Serving metrics", "url", "http://"+addr+"/metrics")
 }
 
-func parseFile(filename string, cacheClient cache.DistributedCache) {
+// dialectSniffLines is the number of lines read to detect the dialect.
+const dialectSniffLines = 50
+
+// detectDialect sniffs filename and, depending on conf.DialectDetection, applies the detected dialect to conf
+// or returns an error listing the settings that disagree with it.
+func detectDialect(filename string, conf *config.ParserConfig) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	defer file.Close()
+	// Files in other encodings are sniffed as the UTF-8 they are transcoded to
+	var text io.Reader = file
+	if conf.Encoding != "" && conf.Encoding != config.EncodingUTF8 {
+		if text, err = charset.NewReader(file, conf.Encoding); err != nil {
+			return err
+		}
+	}
+	detected, err := dialect.Sniff(text, dialectSniffLines)
+	if err != nil {
+		return err
+	}
+	slog.Info("Detected dialect", "dialect", detected.String())
+	// A UTF-8 byte order mark is stripped when the file is read; others need Encoding
+	if detected.BOM != "" && detected.BOM != "UTF-8" {
+		slog.Warn("The file starts with a byte order mark; set Encoding to read it", "bom", detected.BOM)
+	}
+
+	if conf.DialectDetection == config.DialectAuto {
+		detected.Apply(conf)
+		return nil
+	}
+	if mismatches := detected.Mismatches(conf); len(mismatches) > 0 {
+		return fmt.Errorf("config does not match the file: %s", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// profileFile streams filename through the row reader and writes per-column statistics in format to out,
+// or to stdout when out is empty. No rules run and nothing is written to the cache.
+func profileFile(filename string, conf *config.ParserConfig, format, out string) error {
+	if err := profile.CheckFormat(format); err != nil {
+		return err
+	}
+	rows, header, err := pipeline.Open(filename, conf, true)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	profiler := profile.NewProfiler(conf, header)
+	for rows.Next() {
+		profiler.Add(rows.Row().Cols)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if out != "" {
+		if w, err = os.Create(out); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+	report := profiler.Report(filename)
+	if err = report.Write(w, format); err != nil {
+		return err
+	}
+	slog.Info("Profiled file", "rows", report.Rows, "columns", len(report.Columns))
+	return nil
+}
+
+// inferSchema infers column types from the first sampleRows data rows of filename and writes a starter config to out.
+func inferSchema(filename string, conf *config.ParserConfig, out string, sampleRows int) error {
+	rows, header, err := pipeline.Open(filename, conf, true)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	inferrer := schema.NewInferrer(header)
+	sampled := 0
+	for sampled < sampleRows && rows.Next() {
+		inferrer.Add(rows.Row().Cols)
+		sampled++
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(inferrer.StarterConfig(conf), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(out, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	slog.Info("Inferred schema; starter config written", "columns", len(inferrer.Columns()), "rows", sampled, "config", out)
+	return nil
+}
+
+// parseFile validates every row of filename and writes the results to the cache.
+// When manifestFile is set the file's size and checksum are verified first, and nothing is parsed if they do not match.
+// It returns an error when the manifest or a file-level aggregate rule is violated.
+// When reg is not nil the pipeline's metrics are registered with it. Row counts, rejections and the manifest outcome
+// are recorded in run.
+func parseFile(filename, manifestFile string, conf *config.ParserConfig, cacheClient cache.DistributedCache, reg *metrics.Registry, run *report.Run) error {
+	var fileManifest *manifest.Manifest
+	if manifestFile != "" {
+		m, err := manifest.Load(manifestFile)
+		if err == nil && m.SHA256 == "" {
+			err = m.VerifyFile(filename)
+		} else if err == nil {
+			// The run report reads the whole file for its checksum anyway; verify against that instead of a second read.
+			var sum string
+			var size int64
+			if sum, size, err = run.FileSum(); err == nil {
+				err = m.VerifySum(size, sum)
+			}
+		}
+		if err != nil {
+			recordManifest(cacheClient, filename, err)
+			run.SetManifest(manifestFile, m, 0, err)
+			return fmt.Errorf("manifest verification: %w", err)
+		}
+		slog.Info("Manifest size and checksum verified", "manifest", manifestFile)
+		fileManifest = &m
+	}
+
+	rows, _, err := pipeline.Open(filename, conf, false)
+	if err != nil {
+		return err
 	}
 	defer func() {
-		fcErr := file.Close()
+		fcErr := rows.Close()
 		if fcErr != nil {
 			panic(fcErr)
 		}
 	}()
-	conf, err := config.LoadParserConfig("config.json")
+	activeRules, err := loan_info.ActiveRules(conf)
 	if err != nil {
 		panic(err)
 	}
-	chanWg := &sync.WaitGroup{}
-	cacheChan := validator.NewCacheChannel(cacheClient, chanWg, cachePoolSize)
-	// Create a pool of validators
-	pool := loan_info.NewRowValidatorPool(&conf, cacheChan, rowPoolSize)
-	// Ensure validators are closed when function exits
-	defer loan_info.CloseValidatorPool(pool)
-
-	// Create a channel to receive errors
-	errChan := NewErrChan(cacheClient, errPoolSize, chanWg)
-	var rowCount int64 = 0
-	scanner := bufio.NewScanner(file)
-	const maxScannerBufferSize = 1024 * 1024 // 1MB buffer
-	buf := make([]byte, maxScannerBufferSize)
-	scanner.Buffer(buf, maxScannerBufferSize)
-	wg := &sync.WaitGroup{}
-
-	times := make([]int, 10)
+	aggregateRules := loan_info.AggregateRules(conf)
+	newValidators := func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error) {
+		return loan_info.NewRowValidatorPool(conf, cacheChan, n, aggregateRules...)
+	}
+	var seen validator.SeenStore = validator.NewMemorySeenStore()
+	if conf.DuplicateCache {
+		seen = validator.NewCacheSeenStore(cacheClient)
+	}
+	duplicates := validator.NewDuplicateDetector(conf, seen, filename, loan_info.DuplicateKeys)
+	p, err := pipeline.New(conf, cacheClient, newValidators, duplicates)
+	if err != nil {
+		return err
+	}
+	if reg != nil {
+		p.Instrument(reg)
+	}
+	p.OnReject(run.Reject)
+
+	// One entry per pipeline.ProgressInterval rows
+	times := make([]int, 0, 64)
 	prevTime := time.Now()
-	for scanner.Scan() {
-		currentRow := rowCount
-		if currentRow == 0 && conf.HasHeader {
-			rowCount++
-			continue
-		}
-		rowVal := <-pool
-		wg.Add(1)
-		go func(row string, rowNum int64) {
-			defer wg.Done()
-			id, rowErr := rowVal.Validate(row)
-			if rowErr != nil {
-				errChan <- validator.RowError{
-					Row:   rowNum,
-					Id:    id,
-					Error: rowErr,
-				}
-			}
-			pool <- rowVal
-		}(scanner.Text(), currentRow)
-		if currentRow%10000 == 0 {
-			log.Printf("Processed %d rows\n", currentRow)
+	stats, err := p.Run(rows, func(row int64) {
+		slog.Info("Processed rows", "rows", row)
+		// ReadMemStats stops the world, so memory is only read when it is logged
+		if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
 			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
-			fmt.Printf("Alloc = %v MiB", m.Alloc/1024/1024)
-			fmt.Printf("\tTotalAlloc = %v MiB", m.TotalAlloc/1024/1024)
-			fmt.Printf("\tSys = %v MiB", m.Sys/1024/1024)
-			fmt.Printf("\tNumGC = %v\n", m.NumGC)
-
-			now := time.Now()
-			diffMs := now.Sub(prevTime).Milliseconds()
-			times = append(times, int(diffMs))
-			prevTime = now
+			slog.Debug("Memory", "alloc_mib", m.Alloc>>20, "total_alloc_mib", m.TotalAlloc>>20, "sys_mib", m.Sys>>20, "num_gc", m.NumGC)
+		}
 
+		now := time.Now()
+		diffMs := now.Sub(prevTime).Milliseconds()
+		times = append(times, int(diffMs))
+		prevTime = now
+	})
+	run.SetPipeline(p.Config())
+	if err != nil {
+		run.SetRows(stats.Rows, stats.ValidRows, stats.InvalidRows+stats.Duplicates, stats.Duplicates)
+		return err
+	}
+	slog.Info("CSV parsing complete")
+	// The policy can drop accepted rows, so it is applied before the rows are counted and the aggregates checked
+	var dropped int64
+	if duplicates != nil {
+		dropped = applyDuplicatePolicy(duplicates, conf, cacheClient, aggregateRules, p.Reject)
+	}
+	accepted := stats.ValidRows - dropped
+	run.SetRows(stats.Rows, accepted, stats.InvalidRows+stats.Duplicates+dropped, stats.Duplicates)
+	aggregateErrs := validator.CheckAggregates(aggregateRules, validator.FileStats{
+		Rows:      stats.Rows,
+		ValidRows: accepted,
+	})
+	run.SetAggregateFailures(aggregateErrs)
+	slog.Info("Finished writing to cache")
+	// Files shorter than pipeline.ProgressInterval rows have no timings
+	if len(times) > 0 {
+		avgTime := 0
+		for _, t := range times {
+			avgTime += t
 		}
-		rowCount++
+		avgTime /= len(times)
+		slog.Info("Average time per 10,000 rows", "ms", avgTime)
+	}
+	// Total rows includes the header
+	slog.Info("Total rows", "rows", stats.LastRow+1)
+	if duplicates != nil {
+		slog.Info("Duplicate rows", "rows", stats.Duplicates, "policy", conf.DuplicatePolicy)
+	}
+	pc := p.Config()
+	slog.Info("Pipeline", "parse_workers", pc.ParseWorkers, "validate_workers", pc.ValidateWorkers, "write_workers", pc.WriteWorkers,
+		"queue_size", pc.QueueSize, "memory_budget_mb", pc.MemoryBudgetMB)
+	if pc.Autotune {
+		slog.Info("Autotune settled; set Pipeline.ValidateWorkers and Pipeline.WriteWorkers to start from these sizes",
+			"validate_workers", pc.ValidateWorkers, "write_workers", pc.WriteWorkers)
+	}
+	slog.Info("Active rules", "rules", strings.Join(activeRules, ", "))
+	var manifestErr error
+	if fileManifest != nil {
+		manifestErr = fileManifest.VerifyRows(stats.Rows)
+		recordManifest(cacheClient, filename, manifestErr)
+		run.SetManifest(manifestFile, *fileManifest, stats.Rows, manifestErr)
+	}
+	return errors.Join(manifestErr, recordAggregateErrors(cacheClient, filename, aggregateErrs))
+}
 
+// recordManifest logs the manifest verification outcome and writes it to the cache under manifest:<filename>.
+func recordManifest(cacheClient cache.DistributedCache, filename string, verifyErr error) {
+	outcome := "verified"
+	if verifyErr != nil {
+		outcome = "failed: " + verifyErr.Error()
 	}
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error scanning file: %v", err)
+	slog.Info("Manifest verification", "outcome", outcome)
+	cacheErr := cacheClient.Set(context.Background(), "manifest:"+filename, outcome)
+	if cacheErr != nil {
+		slog.Error("Error writing to cache", "err", cacheErr)
 	}
+}
+
+// recordAggregateErrors writes each failed aggregate rule to the cache under err:file:<filename>:<rule>
+// and returns them joined, or nil if every rule passed.
+func recordAggregateErrors(cacheClient cache.DistributedCache, filename string, aggregateErrs []*validator.AggregateError) error {
+	errs := make([]error, 0, len(aggregateErrs))
+	for _, aggErr := range aggregateErrs {
+		slog.Warn("Aggregate rule failed", "rule", aggErr.Rule, "err", aggErr.Err)
+		cacheErr := cacheClient.Set(context.Background(), fmt.Sprintf("err:file:%s:%s", filename, aggErr.Rule), aggErr.Err.Error())
+		if cacheErr != nil {
+			slog.Error("Error writing to cache", "err", cacheErr)
+		}
+		errs = append(errs, aggErr)
+	}
+	return errors.Join(errs...)
+}
 
-	wg.Wait()
-	log.Println("CSV parsing complete.")
-	close(errChan)
-	close(cacheChan)
-	chanWg.Wait()
-	log.Println("Finished writing to cache.")
-	avgTime := 0
-	for _, t := range times {
-		avgTime += t
+// applyDuplicatePolicy deletes or replaces the records of repeated rows once every record from the file has been written,
+// and returns the number of accepted rows it dropped.
+// A keep-last repeat is validated before the record it replaces is deleted, and Finish writes it.
+// One that fails validation was already rejected as a duplicate, so it is only logged and the first row is kept.
+// Each first row that is deleted or replaced is taken out of aggregateRules, and a repeat written in its place is added.
+// A first row dropped under reject is passed to reject and recorded as a row error naming the repeat.
+func applyDuplicatePolicy(duplicates *validator.DuplicateDetector, conf *config.ParserConfig, cacheClient cache.DistributedCache,
+	aggregateRules []validator.AggregateRule, reject func(error)) int64 {
+	ctx := context.Background()
+	// Nothing reads cacheChan: a valid row's record is taken from it and handed back to Finish or dropped
+	cacheChan := make(chan validator.CacheData, 1)
+	pool, err := loan_info.NewRowValidatorPool(conf, cacheChan, 1, aggregateRules...)
+	if err != nil {
+		panic(err)
+	}
+	// Validating a first row again with these rules takes it out of the aggregates
+	removePool, err := loan_info.NewRowValidatorPool(conf, cacheChan, 1, validator.Removing(aggregateRules)...)
+	if err != nil {
+		panic(err)
+	}
+	rowVal, removeVal := <-pool, <-removePool
+	var dropped int64
+	err = duplicates.Finish(ctx, cacheClient, func(raw string, cols []string, rowNum int64) (validator.CacheData, error) {
+		id, rowErr := rowVal.ValidateColumns(raw, cols)
+		if rowErr != nil {
+			slog.Info("Last duplicate failed validation, keeping the first row", "row", rowNum, "id", id,
+				"code", validator.ErrorCode(rowErr), "err", rowErr)
+			return validator.CacheData{}, rowErr
+		}
+		return <-cacheChan, nil
+	}, func(first validator.Occurrence, raw string, cols []string, dupErr *validator.DuplicateError) {
+		if len(aggregateRules) > 0 && cols != nil {
+			if _, rowErr := removeVal.ValidateColumns(raw, cols); rowErr == nil {
+				validator.PutMap((<-cacheChan).Data)
+			}
+		}
+		if dupErr == nil {
+			return
+		}
+		dropped++
+		reject(dupErr)
+		pipeline.WriteRowError(ctx, cacheClient, validator.RowError{
+			Row:   first.Row,
+			Line:  first.Row + 1,
+			Id:    first.Id,
+			Error: dupErr,
+		})
+	})
+	pool <- rowVal
+	loan_info.CloseValidatorPool(pool)
+	removePool <- removeVal
+	loan_info.CloseValidatorPool(removePool)
+	if err != nil {
+		slog.Error("Error applying duplicate policy", "err", err)
 	}
-	avgTime /= len(times)
-	log.Printf("Average time per 10,000 rows: %dms", avgTime)
-	log.Printf("Total rows: %d", rowCount)
-	log.Printf("Cache Pool Size: %d", cachePoolSize)
-	log.Printf("Error Pool Size: %d", errPoolSize)
-	log.Printf("Row Pool Size: %d", rowPoolSize)
+	return dropped
 }
@@ -0,0 +1,144 @@
+// Package manifest verifies a data file against the control file sent alongside it.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidManifest  = errors.New("invalid manifest")
+	ErrSizeMismatch     = errors.New("file size does not match the manifest")
+	ErrChecksumMismatch = errors.New("file SHA-256 does not match the manifest")
+	ErrRowsMismatch     = errors.New("row count does not match the manifest")
+)
+
+// Manifest is the expected shape of a data file. Zero values are not checked.
+type Manifest struct {
+	// Rows is the number of data rows, excluding the header.
+	Rows int64 `json:"rows"`
+	// Bytes is the file size.
+	Bytes int64 `json:"bytes"`
+	// SHA256 is the hex-encoded checksum of the whole file.
+	SHA256 string `json:"sha256"`
+}
+
+// Load reads a manifest written either as a JSON object or as key=value lines:
+//
+//	rows=2260668
+//	bytes=1609232541
+//	sha256=9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//
+// Blank lines and lines starting with '#' are ignored in the key=value form.
+func Load(filename string) (Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var m Manifest
+		if err = json.Unmarshal(trimmed, &m); err != nil {
+			return Manifest{}, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+		}
+		return m, m.validate()
+	}
+	return parseKeyValue(data)
+}
+
+func parseKeyValue(data []byte) (Manifest, error) {
+	var m Manifest
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Manifest{}, fmt.Errorf("%w: line %d is not key=value", ErrInvalidManifest, n)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "rows":
+			m.Rows, err = strconv.ParseInt(value, 10, 64)
+		case "bytes":
+			m.Bytes, err = strconv.ParseInt(value, 10, 64)
+		case "sha256":
+			m.SHA256 = value
+		default:
+			return Manifest{}, fmt.Errorf("%w: unknown key %q on line %d", ErrInvalidManifest, key, n)
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("%w: %s on line %d: %v", ErrInvalidManifest, key, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Manifest{}, err
+	}
+	return m, m.validate()
+}
+
+func (m *Manifest) validate() error {
+	if m.Rows < 0 || m.Bytes < 0 {
+		return fmt.Errorf("%w: rows and bytes must not be negative", ErrInvalidManifest)
+	}
+	if m.SHA256 != "" {
+		m.SHA256 = strings.ToLower(m.SHA256)
+		if decoded, err := hex.DecodeString(m.SHA256); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("%w: sha256 is not a 64-character hex string", ErrInvalidManifest)
+		}
+	}
+	return nil
+}
+
+// VerifyFile checks the size and checksum of filename. The file is read once, and only when a checksum is expected.
+func (m Manifest) VerifyFile(filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	// The size is checked before the file is read, so a truncated file is not hashed.
+	if err = (Manifest{Bytes: m.Bytes}).VerifySum(info.Size(), ""); err != nil || m.SHA256 == "" {
+		return err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return err
+	}
+	return m.VerifySum(info.Size(), hex.EncodeToString(hash.Sum(nil)))
+}
+
+// VerifySum checks a file size and hex SHA-256 computed by the caller, for a file that is read anyway.
+func (m Manifest) VerifySum(size int64, sum string) error {
+	if m.Bytes > 0 && size != m.Bytes {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrSizeMismatch, size, m.Bytes)
+	}
+	if m.SHA256 != "" && sum != m.SHA256 {
+		return fmt.Errorf("%w: got %s", ErrChecksumMismatch, sum)
+	}
+	return nil
+}
+
+// VerifyRows checks the number of data rows read from the file.
+func (m Manifest) VerifyRows(rows int64) error {
+	if m.Rows > 0 && rows != m.Rows {
+		return fmt.Errorf("%w: read %d, expected %d", ErrRowsMismatch, rows, m.Rows)
+	}
+	return nil
+}
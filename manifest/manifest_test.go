@@ -0,0 +1,121 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testData = "id,loan_amnt\n1,3600\n2,24700\n"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testDataSum() string {
+	sum := sha256.Sum256([]byte(testData))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	want := Manifest{Rows: 2, Bytes: 28, SHA256: testDataSum()}
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "json", content: `{"rows": 2, "bytes": 28, "sha256": "` + testDataSum() + `"}`},
+		{name: "key value", content: "# control file\nrows=2\nbytes = 28\n\nSHA256=" + testDataSum() + "\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Load(writeFile(t, "data.manifest", tc.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m != want {
+				t.Errorf("expected %+v, got %+v", want, m)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "bad json", content: `{"rows": "two"}`},
+		{name: "missing separator", content: "rows 2"},
+		{name: "unknown key", content: "rowcount=2"},
+		{name: "bad number", content: "bytes=lots"},
+		{name: "negative rows", content: "rows=-1"},
+		{name: "short checksum", content: "sha256=abc123"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, "data.manifest", tc.content))
+			if !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("expected invalid manifest error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := writeFile(t, "data.csv", testData)
+	testCases := []struct {
+		name     string
+		manifest Manifest
+		wantErr  error
+	}{
+		{name: "matches", manifest: Manifest{Bytes: 28, SHA256: testDataSum()}},
+		{name: "nothing to check", manifest: Manifest{}},
+		{name: "wrong size", manifest: Manifest{Bytes: 27, SHA256: testDataSum()}, wantErr: ErrSizeMismatch},
+		{name: "wrong checksum", manifest: Manifest{SHA256: hex.EncodeToString(make([]byte, 32))}, wantErr: ErrChecksumMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.manifest.VerifyFile(path)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifySum(t *testing.T) {
+	m := Manifest{Bytes: 28, SHA256: testDataSum()}
+	if err := m.VerifySum(28, testDataSum()); err != nil {
+		t.Errorf("expected a match, got %v", err)
+	}
+	if err := m.VerifySum(27, testDataSum()); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", ErrSizeMismatch, err)
+	}
+	if err := m.VerifySum(28, hex.EncodeToString(make([]byte, 32))); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected %v, got %v", ErrChecksumMismatch, err)
+	}
+}
+
+func TestVerifyRows(t *testing.T) {
+	m := Manifest{Rows: 2}
+	if err := m.VerifyRows(2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := m.VerifyRows(3); !errors.Is(err, ErrRowsMismatch) {
+		t.Errorf("expected row count error, got %v", err)
+	}
+	if err := (Manifest{}).VerifyRows(3); err != nil {
+		t.Errorf("expected rows to be unchecked, got %v", err)
+	}
+}
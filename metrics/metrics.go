@@ -0,0 +1,279 @@
+// Package metrics keeps counters, gauges and histograms and serves them in the Prometheus text exposition format.
+// It covers the few metric types the parser exposes with the standard library only, so the module does not depend on
+// the Prometheus client.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// LatencyBuckets are histogram bounds in seconds from 10µs to about 10s, suited to both row validation and cache writes.
+var LatencyBuckets = ExponentialBuckets(0.00001, 4, 11)
+
+// ExponentialBuckets returns count bucket bounds, the first start and each one factor times the one before.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// Label is a name and value attached to one series of a metric.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Registry holds metrics in registration order. Metrics of the same name are exposed as one family,
+// so a gauge can be registered once per label value.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+type family struct {
+	name   string
+	help   string
+	kind   string
+	series []series
+}
+
+// series writes the samples of one metric, each line starting with name.
+type series interface {
+	write(w *bufio.Writer, name string)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(name, help, kind string, s series) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.families {
+		if f.name == name {
+			if f.kind != kind {
+				panic(fmt.Sprintf("metric %s registered as %s and %s", name, f.kind, kind))
+			}
+			f.series = append(f.series, s)
+			return
+		}
+	}
+	r.families = append(r.families, &family{name: name, help: help, kind: kind, series: []series{s}})
+}
+
+// Counter registers a counter. By convention its name ends in _total.
+func (r *Registry) Counter(name, help string, labels ...Label) *Counter {
+	c := &Counter{labels: formatLabels(labels)}
+	r.register(name, help, "counter", c)
+	return c
+}
+
+// CounterVec registers a counter with one series per value of label, created on first use.
+func (r *Registry) CounterVec(name, help, label string) *CounterVec {
+	v := &CounterVec{label: label}
+	r.register(name, help, "counter", v)
+	return v
+}
+
+// CounterFunc registers a counter whose value is read from f each time the metrics are written.
+func (r *Registry) CounterFunc(name, help string, f func() float64, labels ...Label) {
+	r.register(name, help, "counter", &gaugeFunc{labels: formatLabels(labels), f: f})
+}
+
+// GaugeFunc registers a gauge whose value is read from f each time the metrics are written.
+func (r *Registry) GaugeFunc(name, help string, f func() float64, labels ...Label) {
+	r.register(name, help, "gauge", &gaugeFunc{labels: formatLabels(labels), f: f})
+}
+
+// Histogram registers a histogram with the given upper bucket bounds, in increasing order.
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{bounds: slices.Clone(buckets), counts: make([]uint64, len(buckets))}
+	r.register(name, help, "histogram", h)
+	return h
+}
+
+// Write writes every metric to w in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind)
+		for _, s := range f.series {
+			s.write(bw, f.name)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler serves the metrics for a Prometheus scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+// Counter is a count that only goes up. The zero value is not registered; use Registry.Counter.
+type Counter struct {
+	labels string
+	value  atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) write(w *bufio.Writer, name string) {
+	fmt.Fprintf(w, "%s%s %d\n", name, c.labels, c.Value())
+}
+
+// CounterVec is a counter split by the value of one label.
+type CounterVec struct {
+	label    string
+	counters sync.Map
+}
+
+// With returns the counter for value.
+func (v *CounterVec) With(value string) *Counter {
+	if c, ok := v.counters.Load(value); ok {
+		return c.(*Counter)
+	}
+	c, _ := v.counters.LoadOrStore(value, &Counter{labels: formatLabels([]Label{{Name: v.label, Value: value}})})
+	return c.(*Counter)
+}
+
+func (v *CounterVec) write(w *bufio.Writer, name string) {
+	var values []string
+	v.counters.Range(func(key, _ any) bool {
+		values = append(values, key.(string))
+		return true
+	})
+	// Sorted so scrapes list the series in a stable order
+	slices.Sort(values)
+	for _, value := range values {
+		v.With(value).write(w, name)
+	}
+}
+
+type gaugeFunc struct {
+	labels string
+	f      func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer, name string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, g.labels, formatFloat(g.f()))
+}
+
+// Histogram counts observations in buckets and keeps their sum.
+// A mutex guards them, so a scrape never sees a count that disagrees with the buckets or the sum.
+type Histogram struct {
+	bounds []float64
+
+	mu sync.Mutex
+	// counts holds the observations of each bucket alone; they are summed when written
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.bounds) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveDuration observes d in seconds.
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w *bufio.Writer, name string) {
+	// Snapshot under the lock and format outside it, so a slow scrape does not hold up Observe
+	h.mu.Lock()
+	counts := slices.Clone(h.counts)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.Name + `="` + escapeLabel(l.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
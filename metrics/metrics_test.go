@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	rows := r.Counter("rows_read_total", "Rows read.")
+	rejected := r.CounterVec("rows_rejected_total", "Rows rejected, by error code.", "code")
+	r.GaugeFunc("queue_depth", "Rows waiting in a queue.", func() float64 { return 3 }, Label{Name: "queue", Value: "parse"})
+	r.GaugeFunc("queue_depth", "Rows waiting in a queue.", func() float64 { return 0.5 }, Label{Name: "queue", Value: `a"b`})
+	latency := r.Histogram("write_seconds", "Write latency.\nIn seconds.", []float64{0.1, 1})
+
+	rows.Add(2)
+	rows.Inc()
+	rejected.With("term").Inc()
+	rejected.With("loan_amount").Add(2)
+	latency.Observe(0.1)
+	latency.Observe(0.5)
+	latency.Observe(4)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP rows_read_total Rows read.
+# TYPE rows_read_total counter
+rows_read_total 3
+# HELP rows_rejected_total Rows rejected, by error code.
+# TYPE rows_rejected_total counter
+rows_rejected_total{code="loan_amount"} 2
+rows_rejected_total{code="term"} 1
+# HELP queue_depth Rows waiting in a queue.
+# TYPE queue_depth gauge
+queue_depth{queue="parse"} 3
+queue_depth{queue="a\"b"} 0.5
+# HELP write_seconds Write latency.\nIn seconds.
+# TYPE write_seconds histogram
+write_seconds_bucket{le="0.1"} 1
+write_seconds_bucket{le="1"} 2
+write_seconds_bucket{le="+Inf"} 3
+write_seconds_sum 4.6
+write_seconds_count 3
+`
+	if b.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("rows_read_total", "Rows read.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("expected content type %s, got %s", ContentType, got)
+	}
+	if !strings.Contains(rec.Body.String(), "rows_read_total 1\n") {
+		t.Errorf("expected the counter in the body, got %s", rec.Body.String())
+	}
+}
+
+func TestRegistry_KindMismatch(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("rows_total", "Rows.")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic registering a gauge under a counter's name")
+		}
+	}()
+	r.GaugeFunc("rows_total", "Rows.", func() float64 { return 0 })
+}
+
+func TestHistogram_ConsistentScrape(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("write_seconds", "Write latency.", []float64{1})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000; i++ {
+			h.Observe(0.5)
+		}
+	}()
+	for {
+		var b strings.Builder
+		if err := r.Write(&b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Every observation falls in the first bucket, so all three values must agree
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		bucket := strings.Fields(lines[2])[1]
+		inf := strings.Fields(lines[3])[1]
+		count := strings.Fields(lines[5])[1]
+		if bucket != inf || inf != count {
+			t.Fatalf("inconsistent scrape:\n%s", b.String())
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestRegistry_RegisterRuntime(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterRuntime()
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"# TYPE go_goroutines gauge\n", "# TYPE go_gc_cycles_total counter\n",
+		"go_memstats_heap_alloc_bytes ", "process_start_time_seconds "} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// processStart approximates the process start time by the time the package was initialized.
+var processStart = time.Now()
+
+// RegisterRuntime registers the Go runtime and process metrics a Prometheus client would expose by default:
+// go_info, go_goroutines, heap and total memory, completed GC cycles and process_start_time_seconds.
+func (r *Registry) RegisterRuntime() {
+	r.GaugeFunc("go_info", "Information about the Go environment.", func() float64 { return 1 },
+		Label{Name: "version", Value: runtime.Version()})
+	r.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	r.GaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.",
+		runtimeSample("/memory/classes/heap/objects:bytes"))
+	r.GaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.",
+		runtimeSample("/memory/classes/total:bytes"))
+	r.CounterFunc("go_gc_cycles_total", "Completed GC cycles.", runtimeSample("/gc/cycles/total:gc-cycles"))
+	r.GaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.", func() float64 {
+		return float64(processStart.UnixNano()) / 1e9
+	})
+}
+
+// runtimeSample returns a function reading the runtime/metrics sample name, which must be a uint64 metric.
+// Reading a sample does not stop the world, unlike runtime.ReadMemStats.
+func runtimeSample(name string) func() float64 {
+	return func() float64 {
+		sample := []metrics.Sample{{Name: name}}
+		metrics.Read(sample)
+		if sample[0].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return float64(sample[0].Value.Uint64())
+	}
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"context"
+	"go-file-parsing/cache"
+	"go-file-parsing/metrics"
+	"go-file-parsing/validator"
+	"sync/atomic"
+	"time"
+)
+
+// pipelineMetrics are the instruments of a pipeline. A nil *pipelineMetrics records nothing.
+type pipelineMetrics struct {
+	rowsRead       *metrics.Counter
+	rowsValid      *metrics.Counter
+	rowsRejected   *metrics.CounterVec
+	cacheWrites    *metrics.Counter
+	cacheFailures  *metrics.Counter
+	validation     *metrics.Histogram
+	cacheWrite     *metrics.Histogram
+	busyValidators atomic.Int64
+	// queues are the channels of the run in progress, nil between runs
+	queues atomic.Pointer[runQueues]
+}
+
+type runQueues struct {
+	parse     chan job
+	validate  chan job
+	cacheChan chan validator.CacheData
+	errChan   chan rowError
+}
+
+// Instrument registers the pipeline's metrics with reg: rows read, valid and rejected by validator.ErrorCode,
+// cache writes and failures, the depth of each queue, the busy validators, the heap size, and histograms of
+// validation and cache write latency. Call it before Run.
+func (p *Pipeline) Instrument(reg *metrics.Registry) {
+	m := &pipelineMetrics{
+		rowsRead:      reg.Counter("pipeline_rows_read_total", "Data rows read from the input file."),
+		rowsValid:     reg.Counter("pipeline_rows_valid_total", "Rows that passed validation."),
+		rowsRejected:  reg.CounterVec("pipeline_rows_rejected_total", "Rows rejected, by the rule or input error that rejected them.", "code"),
+		cacheWrites:   reg.Counter("pipeline_cache_writes_total", "Writes to the cache, one per record field or row error."),
+		cacheFailures: reg.Counter("pipeline_cache_write_failures_total", "Writes to the cache that returned an error."),
+		validation:    reg.Histogram("pipeline_validation_seconds", "Time to validate a row.", metrics.LatencyBuckets),
+		cacheWrite:    reg.Histogram("pipeline_cache_write_seconds", "Time of one write to the cache.", metrics.LatencyBuckets),
+	}
+	depth := func(queue func(q *runQueues) int) func() float64 {
+		return func() float64 {
+			q := m.queues.Load()
+			if q == nil {
+				return 0
+			}
+			return float64(queue(q))
+		}
+	}
+	const depthHelp = "Rows waiting in front of a stage."
+	reg.GaugeFunc("pipeline_queue_depth", depthHelp, depth(func(q *runQueues) int { return len(q.parse) }), metrics.Label{Name: "queue", Value: "parse"})
+	reg.GaugeFunc("pipeline_queue_depth", depthHelp, depth(func(q *runQueues) int { return len(q.validate) }), metrics.Label{Name: "queue", Value: "validate"})
+	reg.GaugeFunc("pipeline_queue_depth", depthHelp, depth(func(q *runQueues) int { return len(q.cacheChan) }), metrics.Label{Name: "queue", Value: "cacheChan"})
+	reg.GaugeFunc("pipeline_queue_depth", depthHelp, depth(func(q *runQueues) int { return len(q.errChan) }), metrics.Label{Name: "queue", Value: "errChan"})
+	reg.GaugeFunc("pipeline_busy_validators", "Validate workers validating a row.", func() float64 { return float64(m.busyValidators.Load()) })
+	reg.GaugeFunc("pipeline_heap_bytes", "Memory held by heap objects.", func() float64 { return float64(heapBytes()) })
+
+	p.metrics = m
+	p.cache = &instrumentedCache{DistributedCache: p.cache, m: m}
+}
+
+func (m *pipelineMetrics) setQueues(q *runQueues) {
+	if m != nil {
+		m.queues.Store(q)
+	}
+}
+
+func (m *pipelineMetrics) read() {
+	if m != nil {
+		m.rowsRead.Inc()
+	}
+}
+
+func (m *pipelineMetrics) valid() {
+	if m != nil {
+		m.rowsValid.Inc()
+	}
+}
+
+func (m *pipelineMetrics) rejected(err error) {
+	if m != nil {
+		m.rowsRejected.With(validator.ErrorCode(err)).Inc()
+	}
+}
+
+// validating marks a validator busy and returns the function that marks it done and records the time taken.
+func (m *pipelineMetrics) validating() func() {
+	if m == nil {
+		return func() {}
+	}
+	m.busyValidators.Add(1)
+	start := time.Now()
+	return func() {
+		m.validation.ObserveDuration(time.Since(start))
+		m.busyValidators.Add(-1)
+	}
+}
+
+// instrumentedCache counts and times the writes of the cache it wraps.
+type instrumentedCache struct {
+	cache.DistributedCache
+	m *pipelineMetrics
+}
+
+func (c *instrumentedCache) Set(ctx context.Context, key, value string) error {
+	start := time.Now()
+	err := c.DistributedCache.Set(ctx, key, value)
+	c.observe(start, err)
+	return err
+}
+
+func (c *instrumentedCache) SetField(ctx context.Context, key, field, value string) error {
+	start := time.Now()
+	err := c.DistributedCache.SetField(ctx, key, field, value)
+	c.observe(start, err)
+	return err
+}
+
+func (c *instrumentedCache) SetIfMissing(ctx context.Context, key, value string) (bool, error) {
+	start := time.Now()
+	set, err := c.DistributedCache.SetIfMissing(ctx, key, value)
+	c.observe(start, err)
+	return set, err
+}
+
+func (c *instrumentedCache) observe(start time.Time, err error) {
+	c.m.cacheWrite.ObserveDuration(time.Since(start))
+	c.m.cacheWrites.Inc()
+	if err != nil {
+		c.m.cacheFailures.Inc()
+	}
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"go-file-parsing/config"
+	"go-file-parsing/metrics"
+	"go-file-parsing/validator"
+	"strings"
+	"testing"
+)
+
+func TestInstrument(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateKeepFirst}
+	newValidators := func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error) {
+		pool := make(chan validator.CsvRowValidator, n)
+		for i := 0; i < n; i++ {
+			pool <- validator.New(conf, cacheChan, []validator.ColValidator{validator.NamedRule("name", requireName)})
+		}
+		return pool, nil
+	}
+	keys := []validator.DuplicateKey{{Name: "id", Column: 0}}
+	duplicates := validator.NewDuplicateDetector(conf, validator.NewMemorySeenStore(), "a.csv", keys)
+	c := &failingSetCache{memCache: newMemCache()}
+	p, err := New(conf, c, newValidators, duplicates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reg := metrics.NewRegistry()
+	p.Instrument(reg)
+
+	if _, err = p.Run(newSliceSource("1,alice", "2,", "1,carol", "3,caf\xe9"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b strings.Builder
+	if err = reg.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"pipeline_rows_read_total 4\n",
+		"pipeline_rows_valid_total 1\n",
+		`pipeline_rows_rejected_total{code="duplicate"} 1` + "\n",
+		`pipeline_rows_rejected_total{code="invalid_encoding"} 1` + "\n",
+		`pipeline_rows_rejected_total{code="name"} 1` + "\n",
+		// The valid row's id and raw fields and three row errors, one of which fails
+		"pipeline_cache_writes_total 5\n",
+		"pipeline_cache_write_failures_total 1\n",
+		"pipeline_cache_write_seconds_count 5\n",
+		"pipeline_validation_seconds_count 2\n",
+		`pipeline_queue_depth{queue="cacheChan"} 0` + "\n",
+		"pipeline_busy_validators 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+// failingSetCache fails to write the row error of a duplicate.
+type failingSetCache struct {
+	*memCache
+}
+
+func (f *failingSetCache) Set(ctx context.Context, key, value string) error {
+	if strings.Contains(value, "duplicate") {
+		return errors.New("connection refused")
+	}
+	return f.memCache.Set(ctx, key, value)
+}
@@ -0,0 +1,347 @@
+// Package pipeline validates the rows of a file in four stages: a reader, parse workers that split lines into
+// columns, validate workers that run the rules, and write workers that store records and row errors in the cache.
+//
+// Each stage runs a fixed number of goroutines connected by bounded channels, so a slow stage blocks the ones
+// before it rather than letting rows pile up. The rows between reading and writing are also held to a memory
+// budget, which bounds the pipeline's memory when rows are large even though the queues are long.
+//
+// With autotuning, the validate and write stages start the most workers they may use and a tuner decides how
+// many of them are active, based on the stages' queues, write latency, heap size and throughput.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"go-file-parsing/cache"
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ProgressInterval is the number of rows between calls to the progress function passed to Run.
+const ProgressInterval = 10000
+
+// rowOverhead estimates the memory a row holds beyond twice its raw length (the line and its columns):
+// the column slice and the record map written to the cache.
+const rowOverhead = 4 << 10
+
+// ValidatorFactory returns n validators that send the records of valid rows to cacheChan.
+type ValidatorFactory func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error)
+
+// Stats counts the rows of a run.
+type Stats struct {
+	// Rows is the number of data rows read, ValidRows and InvalidRows the number that passed and failed validation,
+	// and Duplicates the number rejected as repeats before validation.
+	Rows        int64
+	ValidRows   int64
+	InvalidRows int64
+	Duplicates  int64
+	// LastRow is the number of the last row read, counting from 0 at the first line, or -1 if none was read.
+	LastRow int64
+}
+
+// Pipeline validates the rows of a Source and writes the results to a cache.
+type Pipeline struct {
+	conf          config.PipelineConfig
+	budget        int64
+	decoder       validator.RecordDecoder
+	cache         cache.DistributedCache
+	newValidators ValidatorFactory
+	duplicates    *validator.DuplicateDetector
+	tuneInterval  time.Duration
+	metrics       *pipelineMetrics
+	onReject      func(err error)
+}
+
+// job is a row in flight and the share of the memory budget it holds until it is written.
+type job struct {
+	row  Row
+	cost int64
+}
+
+type rowError struct {
+	err  validator.RowError
+	cost int64
+}
+
+// New returns a pipeline sized by conf.Pipeline. Rows are checked against duplicates, if it is not nil, in file order.
+func New(conf *config.ParserConfig, c cache.DistributedCache, newValidators ValidatorFactory, duplicates *validator.DuplicateDetector) (*Pipeline, error) {
+	if err := conf.CheckPipeline(); err != nil {
+		return nil, err
+	}
+	p := &Pipeline{
+		conf:          conf.Pipeline.WithDefaults(),
+		cache:         c,
+		newValidators: newValidators,
+		duplicates:    duplicates,
+		tuneInterval:  tuneInterval,
+	}
+	p.budget = int64(p.conf.MemoryBudgetMB) << 20
+	// Spreadsheet rows are split by the reader, so there is no decoder
+	if conf.Format != config.FormatXLSX {
+		decoder, err := validator.NewRecordDecoder(conf)
+		if err != nil {
+			return nil, err
+		}
+		p.decoder = decoder
+	}
+	return p, nil
+}
+
+// Config returns the stage sizes the pipeline runs with, defaults included.
+// After an autotuned run, ValidateWorkers and WriteWorkers are the sizes the tuner settled on, and the next run starts from them.
+func (p *Pipeline) Config() config.PipelineConfig {
+	return p.conf
+}
+
+// OnReject sets f to be called with the error of every rejected row, duplicates included.
+// It is called concurrently from several stages.
+func (p *Pipeline) OnReject(f func(err error)) {
+	p.onReject = f
+}
+
+// Reject records a row rejected outside Run, such as a first row the reject duplicate policy drops once the file is
+// written, in the metrics and with the OnReject hook.
+func (p *Pipeline) Reject(err error) {
+	p.metrics.rejected(err)
+	if p.onReject != nil {
+		p.onReject(err)
+	}
+}
+
+// Run reads every row of src and returns once each one has been written to the cache as a record or a row error.
+// progress, if not nil, is called from the reader with the number of every ProgressInterval-th row.
+// An error reading src or checking for duplicates stops the run after the rows already read are written.
+func (p *Pipeline) Run(src Source, progress func(row int64)) (Stats, error) {
+	ctx := context.Background()
+	validateWorkers, writeWorkers := p.conf.ValidateWorkers, p.conf.WriteWorkers
+	// Without autotuning the limiters and tuner are nil and every worker is active
+	var validateLimit, writeLimit *limiter
+	var t *tuner
+	if p.conf.Autotune {
+		validateWorkers, writeWorkers = p.conf.MaxValidateWorkers, p.conf.MaxWriteWorkers
+		validateLimit, writeLimit = newLimiter(p.conf.ValidateWorkers), newLimiter(p.conf.WriteWorkers)
+		t = newTuner(validateLimit, writeLimit, p.conf.MaxValidateWorkers, p.conf.MaxWriteWorkers, p.conf.MaxHeapMB)
+	}
+
+	cacheChan := make(chan validator.CacheData, p.conf.QueueSize)
+	pool, err := p.newValidators(cacheChan, validateWorkers)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	budget := semaphore.NewWeighted(p.budget)
+	parseQueue := make(chan job, p.conf.QueueSize)
+	validateQueue := make(chan job, p.conf.QueueSize)
+	errQueue := make(chan rowError, p.conf.QueueSize)
+	var validRows, invalidRows atomic.Int64
+
+	parseWg := &sync.WaitGroup{}
+	for i := 0; i < p.conf.ParseWorkers; i++ {
+		parseWg.Add(1)
+		go func() {
+			defer parseWg.Done()
+			for j := range parseQueue {
+				if j.row.Cols == nil {
+					cols, err := validator.DecodeLine(p.decoder, j.row.Raw)
+					if err != nil {
+						invalidRows.Add(1)
+						p.Reject(err)
+						errQueue <- newRowError(j.row.Num, "", err, j.cost)
+						continue
+					}
+					j.row.Cols = cols
+				}
+				validateQueue <- j
+			}
+		}()
+	}
+
+	validateWg := &sync.WaitGroup{}
+	for i := 0; i < validateWorkers; i++ {
+		v := <-pool
+		validateWg.Add(1)
+		go func() {
+			defer validateWg.Done()
+			defer v.Close()
+			for j := range validateQueue {
+				// A valid row's record goes to cacheChan, where its writer releases the budget
+				validateLimit.acquire()
+				done := p.metrics.validating()
+				id, err := v.ValidateColumns(j.row.Raw, j.row.Cols)
+				done()
+				validateLimit.release()
+				if p.duplicates != nil {
+					// Lets a repeat of an invalid row be validated in its place
+					if dupErr := p.duplicates.Validated(ctx, j.row.Num, err == nil); dupErr != nil {
+						slog.WarnContext(ctx, "Error forgetting the keys of an invalid row", "row", j.row.Num, "id", id, "err", dupErr)
+					}
+				}
+				if err != nil {
+					invalidRows.Add(1)
+					p.Reject(err)
+					errQueue <- newRowError(j.row.Num, id, err, j.cost)
+					continue
+				}
+				validRows.Add(1)
+				p.metrics.valid()
+			}
+		}()
+	}
+
+	writeWg := &sync.WaitGroup{}
+	for i := 0; i < writeWorkers; i++ {
+		writeWg.Add(1)
+		go func() {
+			defer writeWg.Done()
+			p.write(ctx, budget, writeLimit, t, cacheChan, errQueue)
+		}()
+	}
+
+	p.metrics.setQueues(&runQueues{parse: parseQueue, validate: validateQueue, cacheChan: cacheChan, errChan: errQueue})
+	defer p.metrics.setQueues(nil)
+
+	stopTuner := make(chan struct{})
+	if t != nil {
+		queueSize := float64(p.conf.QueueSize)
+		validateDepth := func() float64 { return float64(len(validateQueue)) / queueSize }
+		writeDepth := func() float64 { return float64(max(len(cacheChan), len(errQueue))) / queueSize }
+		go t.run(p.tuneInterval, validateDepth, writeDepth, stopTuner)
+	}
+
+	stats := Stats{LastRow: -1}
+	var runErr error
+	for src.Next() {
+		row := src.Row()
+		stats.Rows++
+		stats.LastRow = row.Num
+		p.metrics.read()
+		if progress != nil && row.Num%ProgressInterval == 0 {
+			progress(row.Num)
+		}
+		cost := p.rowCost(len(row.Raw))
+		// Blocks until earlier rows are written. The context is never cancelled, so Acquire cannot fail
+		_ = budget.Acquire(ctx, cost)
+		if p.duplicates != nil {
+			// Checked here rather than in the validators so the first row of each key follows file order
+			id, dupErr, err := p.checkDuplicate(ctx, &row)
+			if err != nil {
+				budget.Release(cost)
+				runErr = fmt.Errorf("checking for duplicate rows: %w", err)
+				break
+			}
+			if dupErr != nil {
+				stats.Duplicates++
+				p.Reject(dupErr)
+				errQueue <- newRowError(row.Num, id, dupErr, cost)
+				continue
+			}
+		}
+		parseQueue <- job{row: row, cost: cost}
+	}
+	if runErr == nil {
+		if err := src.Err(); err != nil {
+			runErr = fmt.Errorf("reading rows: %w", err)
+		}
+	}
+
+	// Each stage finishes the rows already queued before the next one's input is closed
+	close(parseQueue)
+	parseWg.Wait()
+	close(validateQueue)
+	validateWg.Wait()
+	close(cacheChan)
+	close(errQueue)
+	writeWg.Wait()
+	close(stopTuner)
+	if t != nil {
+		p.conf.ValidateWorkers, p.conf.WriteWorkers = validateLimit.current(), writeLimit.current()
+	}
+
+	stats.ValidRows = validRows.Load()
+	stats.InvalidRows = invalidRows.Load()
+	return stats, runErr
+}
+
+// write stores records and row errors until both channels are closed, releasing each row's budget once it is written.
+// Writes are timed for t when autotuning.
+func (p *Pipeline) write(ctx context.Context, budget *semaphore.Weighted, l *limiter, t *tuner, cacheChan chan validator.CacheData, errQueue chan rowError) {
+	for cacheChan != nil || errQueue != nil {
+		var item validator.CacheData
+		var e rowError
+		var ok, isErr bool
+		select {
+		case item, ok = <-cacheChan:
+			if !ok {
+				cacheChan = nil
+				continue
+			}
+		case e, ok = <-errQueue:
+			if !ok {
+				errQueue = nil
+				continue
+			}
+			isErr = true
+		}
+
+		l.acquire()
+		start := time.Now()
+		if isErr {
+			WriteRowError(ctx, p.cache, e.err)
+			budget.Release(e.cost)
+		} else {
+			validator.WriteCacheData(ctx, p.cache, item)
+			budget.Release(p.rowCost(item.Size))
+		}
+		if t != nil {
+			t.recordWrite(time.Since(start))
+		}
+		l.release()
+	}
+}
+
+// checkDuplicate checks the row against the earlier rows seen by the detector.
+// A line is split here so the parse stage does not split it again; one that cannot be split is left for it to reject.
+func (p *Pipeline) checkDuplicate(ctx context.Context, row *Row) (string, *validator.DuplicateError, error) {
+	if row.Cols == nil {
+		cols, err := validator.DecodeLine(p.decoder, row.Raw)
+		if err != nil {
+			return "", nil, nil
+		}
+		row.Cols = cols
+	}
+	return p.duplicates.CheckColumns(ctx, row.Raw, row.Cols, row.Num)
+}
+
+// rowCost is the share of the memory budget a row of size bytes holds. A row larger than the whole budget
+// takes all of it, so it still runs, alone.
+func (p *Pipeline) rowCost(size int) int64 {
+	return min(2*int64(size)+rowOverhead, p.budget)
+}
+
+func newRowError(rowNum int64, id string, err error, cost int64) rowError {
+	return rowError{
+		err: validator.RowError{
+			Row:   rowNum,
+			Line:  rowNum + 1,
+			Id:    id,
+			Error: err,
+		},
+		cost: cost,
+	}
+}
+
+// WriteRowError writes err to the cache under err:row<row>:id<id>, prefixed with its line number.
+func WriteRowError(ctx context.Context, c cache.DistributedCache, err validator.RowError) {
+	cacheErr := c.Set(ctx, fmt.Sprintf("err:row%s:id%s", strconv.FormatInt(err.Row, 10), err.Id),
+		fmt.Sprintf("line %d: %v", err.Line, err.Error))
+	if cacheErr != nil {
+		slog.WarnContext(ctx, "Error writing row error to cache", "row", err.Row, "id", err.Id, "err", cacheErr)
+	}
+}
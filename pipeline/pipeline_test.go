@@ -0,0 +1,471 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-file-parsing/cache"
+	"go-file-parsing/config"
+	"go-file-parsing/loan_info"
+	"go-file-parsing/validator"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memCache is an in-memory DistributedCache safe for the pipeline's concurrent writers.
+type memCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newMemCache() *memCache {
+	return &memCache{values: make(map[string]string)}
+}
+
+func (m *memCache) Get(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.values[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("missing key")
+}
+
+func (m *memCache) Set(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memCache) SetField(ctx context.Context, key, field, value string) error {
+	return m.Set(ctx, key+"."+field, value)
+}
+
+func (m *memCache) GetField(ctx context.Context, key, field string) (string, error) {
+	return m.Get(ctx, key+"."+field)
+}
+
+func (m *memCache) SetIfMissing(_ context.Context, key, value string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+	m.values[key] = value
+	return true, nil
+}
+
+func (m *memCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memCache) Close() {}
+
+func (m *memCache) get(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[key]
+}
+
+// sliceSource yields rows from memory, failing with err once they run out if it is set.
+type sliceSource struct {
+	rows []Row
+	next int
+	err  error
+}
+
+func newSliceSource(lines ...string) *sliceSource {
+	s := &sliceSource{}
+	for i, line := range lines {
+		s.rows = append(s.rows, Row{Raw: line, Num: int64(i + 1)})
+	}
+	return s
+}
+
+func (s *sliceSource) Next() bool {
+	if s.next == len(s.rows) {
+		return false
+	}
+	s.next++
+	return true
+}
+
+func (s *sliceSource) Row() Row     { return s.rows[s.next-1] }
+func (s *sliceSource) Err() error   { return s.err }
+func (s *sliceSource) Close() error { return nil }
+
+// requireName rejects rows whose second column is empty.
+func requireName(_ *validator.RowValidatorContext, cols []string) (map[string]string, error) {
+	if len(cols) < 2 || cols[1] == "" {
+		return nil, errors.New("missing name")
+	}
+	return nil, nil
+}
+
+func testFactory(conf *config.ParserConfig) ValidatorFactory {
+	return func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error) {
+		pool := make(chan validator.CsvRowValidator, n)
+		for i := 0; i < n; i++ {
+			pool <- validator.New(conf, cacheChan, []validator.ColValidator{requireName})
+		}
+		return pool, nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ","}
+	c := newMemCache()
+	p, err := New(conf, c, testFactory(conf), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := p.Run(newSliceSource("1,alice", "2,", "3,bob", "4,caf\xe9"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Stats{Rows: 4, ValidRows: 2, InvalidRows: 2, LastRow: 4}
+	if stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+
+	if got := c.get("1.raw"); got != "1,alice" {
+		t.Errorf("expected record of row 1 to be written, got raw %q", got)
+	}
+	if got := c.get("err:row2:id2"); got != "line 3: missing name" {
+		t.Errorf("expected row error of row 2, got %q", got)
+	}
+	if got := c.get("err:row4:id"); !strings.HasPrefix(got, "line 5: invalid byte sequence") {
+		t.Errorf("expected encoding error of row 4, got %q", got)
+	}
+}
+
+func TestRun_Duplicates(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateKeepFirst}
+	c := newMemCache()
+	keys := []validator.DuplicateKey{{Name: "id", Column: 0}}
+	duplicates := validator.NewDuplicateDetector(conf, validator.NewMemorySeenStore(), "a.csv", keys)
+	p, err := New(conf, c, testFactory(conf), duplicates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := p.Run(newSliceSource("1,alice", "2,bob", "1,carol"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.ValidRows != 2 || stats.Duplicates != 1 {
+		t.Errorf("expected 2 valid rows and 1 duplicate, got %+v", stats)
+	}
+	if got := c.get("1.raw"); got != "1,alice" {
+		t.Errorf("expected the first row to keep id 1, got raw %q", got)
+	}
+	want := "line 4: duplicate id 1 at row 3, first seen at row 1"
+	if got := c.get("err:row3:id1"); got != want {
+		t.Errorf("expected '%s', got '%s'", want, got)
+	}
+}
+
+func TestRun_DuplicateOfInvalidRow(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateKeepFirst}
+	c := newMemCache()
+	keys := []validator.DuplicateKey{{Name: "id", Column: 0}}
+	duplicates := validator.NewDuplicateDetector(conf, validator.NewMemorySeenStore(), "a.csv", keys)
+	p, err := New(conf, c, testFactory(conf), duplicates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := p.Run(newSliceSource("1,", "1,bob", "1,carol"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Stats{Rows: 3, ValidRows: 1, InvalidRows: 1, Duplicates: 1, LastRow: 3}
+	if stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+	if got := c.get("1.raw"); got != "1,bob" {
+		t.Errorf("expected the valid second copy to keep id 1, got raw %q", got)
+	}
+	want3 := "line 4: duplicate id 1 at row 3, first seen at row 2"
+	if got := c.get("err:row3:id1"); got != want3 {
+		t.Errorf("expected '%s', got '%s'", want3, got)
+	}
+}
+
+func TestRun_SourceError(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ","}
+	c := newMemCache()
+	p, err := New(conf, c, testFactory(conf), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src := newSliceSource("1,alice")
+	src.err = errors.New("token too long")
+	stats, err := p.Run(src, nil)
+	if err == nil || !strings.Contains(err.Error(), "token too long") {
+		t.Errorf("expected the source error, got %v", err)
+	}
+	if stats.ValidRows != 1 || c.get("1.raw") == "" {
+		t.Errorf("expected the row read before the error to be written, got %+v", stats)
+	}
+}
+
+func TestRun_MemoryBudget(t *testing.T) {
+	conf := &config.ParserConfig{
+		Delimiter: ",",
+		Pipeline:  config.PipelineConfig{ParseWorkers: 2, ValidateWorkers: 2, WriteWorkers: 2, MemoryBudgetMB: 1},
+	}
+	c := &budgetCache{memCache: newMemCache()}
+	p, err := New(conf, c, testFactory(conf), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Rows of 200KB hold 400KB of the budget each, so at most two are in flight;
+	// a 2MB row is larger than the whole budget and still runs, on its own.
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("%d,%s", i, strings.Repeat("x", 200<<10)))
+	}
+	lines = append(lines, "20,"+strings.Repeat("x", 2<<20))
+	stats, err := p.Run(newSliceSource(lines...), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.ValidRows != int64(len(lines)) {
+		t.Errorf("expected %d valid rows, got %+v", len(lines), stats)
+	}
+	if peak := c.peak.Load(); peak > 2 {
+		t.Errorf("expected at most 2 rows being written at once, got %d", peak)
+	}
+}
+
+// budgetCache counts the records being written at once.
+type budgetCache struct {
+	*memCache
+	writing atomic.Int64
+	peak    atomic.Int64
+}
+
+func (b *budgetCache) SetField(ctx context.Context, key, field, value string) error {
+	if field != "raw" {
+		return b.memCache.SetField(ctx, key, field, value)
+	}
+	n := b.writing.Add(1)
+	defer b.writing.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	// Hold the write so other rows queue up behind the budget
+	time.Sleep(time.Millisecond)
+	return b.memCache.SetField(ctx, key, field, value)
+}
+
+// benchRows is the number of rows each benchmark iteration validates.
+const benchRows = 20000
+
+// writeLatency simulates the round trip of a cache write.
+const writeLatency = 50 * time.Microsecond
+
+// latencyCache discards writes after waiting writeLatency, so benchmarks measure the pipeline rather than a store.
+type latencyCache struct{}
+
+func (latencyCache) Get(context.Context, string) (string, error) {
+	return "", errors.New("missing key")
+}
+func (latencyCache) Set(context.Context, string, string) error {
+	time.Sleep(writeLatency)
+	return nil
+}
+func (latencyCache) SetIfMissing(context.Context, string, string) (bool, error) {
+	time.Sleep(writeLatency)
+	return true, nil
+}
+func (latencyCache) SetField(context.Context, string, string, string) error {
+	time.Sleep(writeLatency)
+	return nil
+}
+func (latencyCache) GetField(context.Context, string, string) (string, error) {
+	return "", errors.New("missing key")
+}
+func (latencyCache) Delete(context.Context, string) error { return nil }
+func (latencyCache) Close()                               {}
+
+// benchLines returns benchRows data lines of sample.csv, repeated.
+func benchLines(b *testing.B) []string {
+	file, err := os.Open("../sample.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	scanner := newRowScanner(file)
+	scanner.Scan() // header
+	var sample []string
+	for scanner.Scan() {
+		sample = append(sample, scanner.Text())
+	}
+	lines := make([]string, benchRows)
+	for i := range lines {
+		lines[i] = sample[i%len(sample)]
+	}
+	return lines
+}
+
+func benchConfig(b *testing.B) *config.ParserConfig {
+	conf, err := config.LoadParserConfig("../config.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return &conf
+}
+
+// peakHeap samples the heap until stop is closed and returns the largest size seen, in MB.
+func peakHeap(stop chan struct{}) chan float64 {
+	result := make(chan float64, 1)
+	go func() {
+		var peak uint64
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+			peak = max(peak, m.HeapAlloc)
+			select {
+			case <-stop:
+				result <- float64(peak) / (1 << 20)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return result
+}
+
+func runBenchmark(b *testing.B, run func(lines []string)) {
+	lines := benchLines(b)
+	runtime.GC()
+	stop := make(chan struct{})
+	peak := peakHeap(stop)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		run(lines)
+	}
+	elapsed := time.Since(start)
+	b.StopTimer()
+	close(stop)
+	b.ReportMetric(float64(b.N*len(lines))/elapsed.Seconds(), "rows/s")
+	b.ReportMetric(<-peak, "peak-heap-MB")
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	conf := benchConfig(b)
+	newValidators := func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error) {
+		return loan_info.NewRowValidatorPool(conf, cacheChan, n)
+	}
+	p, err := New(conf, latencyCache{}, newValidators, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runBenchmark(b, func(lines []string) {
+		if _, err := p.Run(newSliceSource(lines...), nil); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+// BenchmarkPipelineAutotune starts from one validate and one write worker; the sizes the tuner settles on carry
+// over from one iteration to the next.
+func BenchmarkPipelineAutotune(b *testing.B) {
+	conf := benchConfig(b)
+	conf.Pipeline = config.PipelineConfig{ValidateWorkers: 1, WriteWorkers: 1, Autotune: true}
+	newValidators := func(cacheChan chan validator.CacheData, n int) (chan validator.CsvRowValidator, error) {
+		return loan_info.NewRowValidatorPool(conf, cacheChan, n)
+	}
+	p, err := New(conf, latencyCache{}, newValidators, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	p.tuneInterval = 100 * time.Millisecond
+	runBenchmark(b, func(lines []string) {
+		if _, err := p.Run(newSliceSource(lines...), nil); err != nil {
+			b.Fatal(err)
+		}
+	})
+	b.ReportMetric(float64(p.Config().WriteWorkers), "write-workers")
+}
+
+// BenchmarkGoroutinePerRow replicates the design the pipeline replaced: a goroutine per row drawing from a pool
+// of 1000 validators, and cache and error channels that start a goroutine per item from pools of 10000 workers.
+func BenchmarkGoroutinePerRow(b *testing.B) {
+	const (
+		cachePoolSize = 10000
+		errPoolSize   = 10000
+		rowPoolSize   = 1000
+	)
+	conf := benchConfig(b)
+	var c cache.DistributedCache = latencyCache{}
+	runBenchmark(b, func(lines []string) {
+		chanWg := &sync.WaitGroup{}
+		cacheChan := legacyChannel(chanWg, cachePoolSize, func(item validator.CacheData) {
+			validator.WriteCacheData(context.Background(), c, item)
+		})
+		errChan := legacyChannel(chanWg, errPoolSize, func(err validator.RowError) {
+			WriteRowError(context.Background(), c, err)
+		})
+		pool, err := loan_info.NewRowValidatorPool(conf, cacheChan, rowPoolSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wg := &sync.WaitGroup{}
+		for i, line := range lines {
+			rowVal := <-pool
+			wg.Add(1)
+			go func(row string, rowNum int64) {
+				defer wg.Done()
+				id, rowErr := rowVal.Validate(row)
+				if rowErr != nil {
+					errChan <- validator.RowError{Row: rowNum, Line: rowNum + 1, Id: id, Error: rowErr}
+				}
+				pool <- rowVal
+			}(line, int64(i+1))
+		}
+		wg.Wait()
+		loan_info.CloseValidatorPool(pool)
+		close(errChan)
+		close(cacheChan)
+		chanWg.Wait()
+	})
+}
+
+// legacyChannel returns a channel of size items, each handled by a new goroutine once one of size workers is free.
+func legacyChannel[T any](wg *sync.WaitGroup, size int, handle func(T)) chan T {
+	ch := make(chan T, size)
+	workers := make(chan struct{}, size)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		itemWg := &sync.WaitGroup{}
+		for item := range ch {
+			workers <- struct{}{}
+			itemWg.Add(1)
+			go func(item T) {
+				defer itemWg.Done()
+				handle(item)
+				<-workers
+			}(item)
+		}
+		itemWg.Wait()
+	}()
+	return ch
+}
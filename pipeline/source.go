@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"go-file-parsing/charset"
+	"go-file-parsing/config"
+	"go-file-parsing/validator"
+	"go-file-parsing/xlsx"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Row is one data row of the input file.
+type Row struct {
+	// Raw is the row as read: the line, or the cells of a spreadsheet row joined by tabs.
+	Raw string
+	// Cols is set when the source split the row itself. Otherwise Raw is split by the parse stage.
+	Cols []string
+	// Num counts rows from 0 at the first line or spreadsheet row, header included.
+	Num int64
+}
+
+// Source yields the data rows of the input file, after its header.
+type Source interface {
+	// Next advances to the next row and reports whether there is one.
+	Next() bool
+	Row() Row
+	// Err returns the error that stopped Next, if any.
+	Err() error
+	Close() error
+}
+
+// Open opens filename in conf.Format and returns its data rows and column names: the header when the file
+// has one, otherwise the names the config lays out for fixed-width or JSON Lines input, if any.
+// When decode is set, lines are split into columns as they are read and a line that cannot be split stops the source.
+func Open(filename string, conf *config.ParserConfig, decode bool) (Source, []string, error) {
+	if conf.Format == config.FormatXLSX {
+		return openXLSXRows(filename, conf)
+	}
+	decoder, err := validator.NewRecordDecoder(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := charset.NewReader(file, conf.Encoding)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if conf.Encoding == config.EncodingAuto {
+		slog.Info("Detected encoding", "encoding", text.Encoding())
+	}
+	s := &lineSource{file: file, scanner: newRowScanner(text)}
+	if decode {
+		s.decoder = decoder
+	}
+	header := conf.FieldNames()
+	if conf.HasHeader && s.scanner.Scan() {
+		s.next++
+		if header, err = decoder.Decode(s.scanner.Text()); err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("header: %w", err)
+		}
+	}
+	return s, header, nil
+}
+
+// newRowScanner returns a line scanner with a buffer large enough for the widest rows.
+func newRowScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	const maxScannerBufferSize = 1024 * 1024 // 1MB buffer
+	buf := make([]byte, maxScannerBufferSize)
+	scanner.Buffer(buf, maxScannerBufferSize)
+	return scanner
+}
+
+type lineSource struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	// decoder is nil when lines are left for the validators to split
+	decoder validator.RecordDecoder
+	row     Row
+	next    int64
+	err     error
+}
+
+func (s *lineSource) Next() bool {
+	if s.err != nil || !s.scanner.Scan() {
+		return false
+	}
+	s.row = Row{Raw: s.scanner.Text(), Num: s.next}
+	s.next++
+	if s.decoder != nil {
+		cols, err := s.decoder.Decode(s.row.Raw)
+		if err != nil {
+			s.err = fmt.Errorf("line %d: %w", s.row.Num+1, err)
+			return false
+		}
+		s.row.Cols = cols
+	}
+	return true
+}
+
+func (s *lineSource) Row() Row {
+	return s.row
+}
+
+func (s *lineSource) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.scanner.Err()
+}
+
+func (s *lineSource) Close() error {
+	return s.file.Close()
+}
+
+func openXLSXRows(filename string, conf *config.ParserConfig) (Source, []string, error) {
+	opts := xlsx.Options{
+		Sheet:      conf.XLSX.Sheet,
+		SheetIndex: conf.XLSX.SheetIndex,
+		DateLayout: conf.XLSX.DateLayout,
+	}
+	if conf.HasHeader {
+		opts.HeaderRow = max(conf.XLSX.HeaderRow, 1)
+	}
+	reader, err := xlsx.Open(filename, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := reader.Header()
+	return &xlsxSource{reader: reader, width: max(len(header), conf.ExpectedColumns)}, header, nil
+}
+
+// xlsxSource reads the rows of one sheet. Rows are numbered by their spreadsheet row, so blank rows leave gaps.
+type xlsxSource struct {
+	reader *xlsx.Reader
+	// width is the number of columns rows are padded to, since a sheet row ends at its last non-empty cell
+	width int
+	row   Row
+	err   error
+}
+
+func (s *xlsxSource) Next() bool {
+	cols, err := s.reader.Read()
+	if err != nil {
+		if err != io.EOF {
+			s.err = fmt.Errorf("sheet %s: %w", s.reader.SheetName(), err)
+		}
+		return false
+	}
+	// The reader reuses its slice, and rows are validated concurrently
+	cols = slices.Clone(cols)
+	for len(cols) < s.width {
+		cols = append(cols, "")
+	}
+	s.row = Row{Raw: strings.Join(cols, "\t"), Cols: cols, Num: s.reader.Row() - 1}
+	return true
+}
+
+func (s *xlsxSource) Row() Row {
+	return s.row
+}
+
+func (s *xlsxSource) Err() error {
+	return s.err
+}
+
+func (s *xlsxSource) Close() error {
+	return s.reader.Close()
+}
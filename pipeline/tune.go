@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"log/slog"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tuneInterval is how often autotuning samples the pipeline and adjusts it.
+const tuneInterval = time.Second
+
+// A change that lowers throughput by more than regressionTolerance is reverted.
+const regressionTolerance = 0.05
+
+// A stage whose queue is fuller than busyQueue is holding back the stage before it.
+const busyQueue = 0.5
+
+// holdIntervals is the number of intervals a stage is left alone after a change to it was reverted.
+const holdIntervals = 10
+
+// limiter caps the number of workers of a stage that process a row at once. The cap can change while they run.
+// A nil limiter does not limit.
+type limiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newLimiter(limit int) *limiter {
+	l := &limiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *limiter) acquire() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+func (l *limiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+func (l *limiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// sample is what the tuner observed over one interval.
+type sample struct {
+	// rate is the number of rows written per second
+	rate float64
+	// validateQueue and writeQueue are how full the queues in front of those stages are, from 0 to 1
+	validateQueue float64
+	writeQueue    float64
+	// writeLatency is the mean time to write a row
+	writeLatency time.Duration
+	heap         uint64
+}
+
+// change is an adjustment whose effect on throughput has not been judged yet.
+type change struct {
+	stage string
+	l     *limiter
+	from  int
+	// rate and latency are the throughput and write latency before the change
+	rate    float64
+	latency time.Duration
+}
+
+// tuner adjusts the number of active validate and write workers by hill climbing: it grows the stage whose queue
+// backs up, keeps the change if throughput improves and reverts it if throughput drops. Validators are shed when
+// the heap is above its ceiling. A change that doubles write latency without raising throughput is also reverted,
+// since the cache is then saturated and more writers only queue up in it.
+type tuner struct {
+	validate    *limiter
+	write       *limiter
+	maxValidate int
+	maxWrite    int
+	maxHeap     uint64
+
+	// Counted by the write workers
+	written    atomic.Int64
+	writeNanos atomic.Int64
+
+	pending       *change
+	holdValidate  int
+	holdWrite     int
+	prevWritten   int64
+	prevWriteTime int64
+}
+
+func newTuner(validate, write *limiter, maxValidate, maxWrite, maxHeapMB int) *tuner {
+	return &tuner{
+		validate:    validate,
+		write:       write,
+		maxValidate: maxValidate,
+		maxWrite:    maxWrite,
+		maxHeap:     uint64(maxHeapMB) << 20,
+	}
+}
+
+// recordWrite counts a row written in d.
+func (t *tuner) recordWrite(d time.Duration) {
+	t.written.Add(1)
+	t.writeNanos.Add(int64(d))
+}
+
+// run samples the pipeline every interval until stop is closed.
+func (t *tuner) run(interval time.Duration, validateQueue, writeQueue func() float64, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		written, writeNanos := t.written.Load(), t.writeNanos.Load()
+		s := sample{
+			rate:          float64(written-t.prevWritten) / interval.Seconds(),
+			validateQueue: validateQueue(),
+			writeQueue:    writeQueue(),
+			heap:          heapBytes(),
+		}
+		if written > t.prevWritten {
+			s.writeLatency = time.Duration((writeNanos - t.prevWriteTime) / (written - t.prevWritten))
+		}
+		t.prevWritten, t.prevWriteTime = written, writeNanos
+		t.step(s)
+	}
+}
+
+// step judges the pending change, if any, and makes the next one.
+func (t *tuner) step(s sample) {
+	t.holdValidate = max(t.holdValidate-1, 0)
+	t.holdWrite = max(t.holdWrite-1, 0)
+	if p := t.pending; p != nil {
+		t.pending = nil
+		lower := s.rate < p.rate*(1-regressionTolerance)
+		saturated := s.rate < p.rate*(1+regressionTolerance) && p.latency > 0 && s.writeLatency > 2*p.latency
+		if lower || saturated {
+			slog.Info("Autotune: reverting", "stage", p.stage, "workers", p.l.current(), "rows_per_second", s.rate,
+				"write_latency", s.writeLatency, "previous_rows_per_second", p.rate, "previous_write_latency", p.latency,
+				"back_to", p.from)
+			p.l.setLimit(p.from)
+			if p.l == t.validate {
+				t.holdValidate = holdIntervals
+			} else {
+				t.holdWrite = holdIntervals
+			}
+			return
+		}
+	}
+
+	switch {
+	case t.maxHeap > 0 && s.heap > t.maxHeap:
+		if n := t.validate.current(); n > 1 {
+			next := max(n*3/4, 1)
+			slog.Info("Autotune: heap over ceiling", "heap_mib", s.heap>>20, "max_heap_mib", t.maxHeap>>20, "validate_workers", next)
+			t.validate.setLimit(next)
+			t.holdValidate = holdIntervals
+		}
+	case s.writeQueue > busyQueue && t.holdWrite == 0 && t.write.current() < t.maxWrite:
+		t.grow("write", t.write, t.maxWrite, s)
+	case s.validateQueue > busyQueue && t.holdValidate == 0 && t.validate.current() < t.maxValidate:
+		t.grow("validate", t.validate, t.maxValidate, s)
+	}
+}
+
+// grow raises l by half, up to limit, and leaves the change pending until the next sample.
+func (t *tuner) grow(stage string, l *limiter, limit int, s sample) {
+	from := l.current()
+	next := min(max(from*3/2, from+1), limit)
+	slog.Info("Autotune: queue backing up", "stage", stage, "rows_per_second", s.rate, "workers", next)
+	l.setLimit(next)
+	t.pending = &change{stage: stage, l: l, from: from, rate: s.rate, latency: s.writeLatency}
+}
+
+// heapBytes returns the memory held by heap objects, without the stop-the-world pause of runtime.ReadMemStats.
+func heapBytes() uint64 {
+	heap := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(heap)
+	if heap[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return heap[0].Value.Uint64()
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"fmt"
+	"go-file-parsing/config"
+	"testing"
+	"time"
+)
+
+func TestTunerStep(t *testing.T) {
+	testCases := []struct {
+		name         string
+		samples      []sample
+		wantValidate int
+		wantWrite    int
+	}{
+		{
+			name:         "idle queues",
+			samples:      []sample{{rate: 1000}, {rate: 1000}},
+			wantValidate: 4, wantWrite: 8,
+		},
+		{
+			name:         "write queue backs up",
+			samples:      []sample{{rate: 1000, writeQueue: 0.9}, {rate: 1500, writeQueue: 0.9}},
+			wantValidate: 4, wantWrite: 18,
+		},
+		{
+			name:         "more writers lower throughput",
+			samples:      []sample{{rate: 1000, writeQueue: 0.9}, {rate: 800, writeQueue: 0.9}, {rate: 1000, writeQueue: 0.9}},
+			wantValidate: 4, wantWrite: 8,
+		},
+		{
+			name: "more writers double write latency",
+			samples: []sample{
+				{rate: 1000, writeQueue: 0.9, writeLatency: time.Millisecond},
+				{rate: 1020, writeQueue: 0.9, writeLatency: 3 * time.Millisecond},
+			},
+			wantValidate: 4, wantWrite: 8,
+		},
+		{
+			name: "slower writes with more throughput",
+			samples: []sample{
+				{rate: 1000, writeQueue: 0.9, writeLatency: time.Millisecond},
+				{rate: 1400, writeQueue: 0.9, writeLatency: 3 * time.Millisecond},
+			},
+			wantValidate: 4, wantWrite: 18,
+		},
+		{
+			name:         "validate queue backs up",
+			samples:      []sample{{rate: 1000, validateQueue: 0.9}, {rate: 1200, validateQueue: 0.9}},
+			wantValidate: 9, wantWrite: 8,
+		},
+		{
+			name:         "validators capped at the maximum",
+			samples:      []sample{{rate: 1, validateQueue: 1}, {rate: 2, validateQueue: 1}, {rate: 3, validateQueue: 1}, {rate: 4, validateQueue: 1}},
+			wantValidate: 10, wantWrite: 8,
+		},
+		{
+			name:         "heap over the ceiling",
+			samples:      []sample{{rate: 1000, validateQueue: 0.9, heap: 2 << 20}},
+			wantValidate: 3, wantWrite: 8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tn := newTuner(newLimiter(4), newLimiter(8), 10, 100, 1)
+			for _, s := range tc.samples {
+				tn.step(s)
+			}
+			if got := tn.validate.current(); got != tc.wantValidate {
+				t.Errorf("expected %d validate workers, got %d", tc.wantValidate, got)
+			}
+			if got := tn.write.current(); got != tc.wantWrite {
+				t.Errorf("expected %d write workers, got %d", tc.wantWrite, got)
+			}
+		})
+	}
+}
+
+func TestRun_Autotune(t *testing.T) {
+	conf := &config.ParserConfig{
+		Delimiter: ",",
+		Pipeline: config.PipelineConfig{
+			ValidateWorkers: 1, WriteWorkers: 1, QueueSize: 4,
+			Autotune: true, MaxValidateWorkers: 2, MaxWriteWorkers: 8,
+		},
+	}
+	c := &budgetCache{memCache: newMemCache()}
+	p, err := New(conf, c, testFactory(conf), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.tuneInterval = 10 * time.Millisecond
+
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("%d,name", i))
+	}
+	stats, err := p.Run(newSliceSource(lines...), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.ValidRows != int64(len(lines)) {
+		t.Errorf("expected %d valid rows, got %+v", len(lines), stats)
+	}
+	// Slow writes back up the write queue, so the tuner adds writers
+	if got := p.Config().WriteWorkers; got <= 1 || got > 8 {
+		t.Errorf("expected between 2 and 8 write workers, got %d", got)
+	}
+	if peak := c.peak.Load(); peak > 8 {
+		t.Errorf("expected at most 8 rows being written at once, got %d", peak)
+	}
+}
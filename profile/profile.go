@@ -0,0 +1,192 @@
+// Package profile computes per-column statistics for a delimited file with bounded memory,
+// to help decide which validation rules a data set needs.
+package profile
+
+import (
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"strconv"
+)
+
+const (
+	// sampleSize is the number of example values kept per column.
+	sampleSize = 10
+	// topKCapacity is the number of candidate frequent values tracked per column.
+	topKCapacity = 32
+	// topValues is the number of frequent values reported per column.
+	topValues = 10
+)
+
+// defaultNumberFormat is used for columns without a NumberFormat in the config,
+// so percentages, dollar amounts and grouped thousands are still profiled as numbers.
+var defaultNumberFormat = utils.NumberFormat{PercentSuffix: true, CurrencyPrefix: "$", GroupingSeparator: ","}
+
+// Report is the profile of one file.
+type Report struct {
+	File    string        `json:"file"`
+	Rows    int64         `json:"rows"`
+	Columns []ColumnStats `json:"columns"`
+}
+
+// ColumnStats are the statistics of one column. Distinct counts are HyperLogLog estimates and
+// top value counts may be overestimated for columns with many distinct values.
+type ColumnStats struct {
+	Index            int          `json:"index"`
+	Name             string       `json:"name"`
+	Count            int64        `json:"count"`
+	Nulls            int64        `json:"nulls"`
+	NullRate         float64      `json:"nullRate"`
+	DistinctEstimate uint64       `json:"distinctEstimate"`
+	Numeric          bool         `json:"numeric"`
+	Min              string       `json:"min,omitempty"`
+	Max              string       `json:"max,omitempty"`
+	TopValues        []ValueCount `json:"topValues,omitempty"`
+	Sample           []string     `json:"sample,omitempty"`
+	Histogram        []Bin        `json:"histogram,omitempty"`
+}
+
+// ValueCount is a value and the number of rows it appeared in.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Bin is a histogram bin covering [Low, High).
+type Bin struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count int64   `json:"count"`
+}
+
+type columnProfile struct {
+	name      string
+	format    utils.NumberFormat
+	count     int64
+	nulls     int64
+	numeric   int64
+	minNum    float64
+	maxNum    float64
+	minStr    string
+	maxStr    string
+	distinct  *hyperLogLog
+	sample    *reservoir
+	top       *topK
+	histogram histogram
+}
+
+// Profiler accumulates column statistics one row at a time. It is not safe for concurrent use.
+type Profiler struct {
+	conf    *config.ParserConfig
+	columns []*columnProfile
+	rows    int64
+}
+
+// NewProfiler returns a profiler for columns named by header. Rows without a header use "col_<index>".
+// Values are normalized with the column's NumberFormat in conf, or defaultNumberFormat, before they are checked for numbers.
+func NewProfiler(conf *config.ParserConfig, header []string) *Profiler {
+	p := &Profiler{conf: conf}
+	for i, name := range header {
+		p.addColumn(i, name)
+	}
+	return p
+}
+
+func (p *Profiler) addColumn(index int, name string) {
+	if name == "" {
+		name = "col_" + strconv.Itoa(index)
+	}
+	format, ok := p.conf.NumberFormat(name)
+	if !ok {
+		format = defaultNumberFormat
+	}
+	p.columns = append(p.columns, &columnProfile{
+		name:     name,
+		format:   format,
+		distinct: newHyperLogLog(),
+		sample:   newReservoir(sampleSize, uint64(index)+1),
+		top:      newTopK(topKCapacity),
+	})
+}
+
+// Add profiles one row of preprocessed columns. Columns missing from a short row count as nulls.
+func (p *Profiler) Add(cols []string) {
+	p.rows++
+	for len(p.columns) < len(cols) {
+		p.addColumn(len(p.columns), "")
+		// Columns first seen now were missing, so null, in every earlier row
+		p.columns[len(p.columns)-1].nulls = p.rows - 1
+	}
+	for i, c := range p.columns {
+		if i >= len(cols) {
+			c.nulls++
+			continue
+		}
+		c.add(cols[i])
+	}
+}
+
+func (c *columnProfile) add(value string) {
+	if value == "" {
+		c.nulls++
+		return
+	}
+	c.count++
+	c.distinct.Add(hashString(value))
+	c.sample.Add(value)
+	c.top.Add(value)
+	if c.count == 1 || value < c.minStr {
+		c.minStr = value
+	}
+	if c.count == 1 || value > c.maxStr {
+		c.maxStr = value
+	}
+
+	d, err := c.format.ParseDecimal(value)
+	if err != nil {
+		return
+	}
+	v := d.Float64()
+	c.numeric++
+	if c.numeric == 1 || v < c.minNum {
+		c.minNum = v
+	}
+	if c.numeric == 1 || v > c.maxNum {
+		c.maxNum = v
+	}
+	c.histogram.Add(v)
+}
+
+// Report returns the statistics gathered so far.
+func (p *Profiler) Report(file string) Report {
+	report := Report{File: file, Rows: p.rows, Columns: make([]ColumnStats, len(p.columns))}
+	for i, c := range p.columns {
+		stats := ColumnStats{
+			Index:            i,
+			Name:             c.name,
+			Count:            c.count,
+			Nulls:            c.nulls,
+			DistinctEstimate: c.distinct.Estimate(),
+			TopValues:        c.top.Top(topValues),
+			Sample:           c.sample.values,
+		}
+		if p.rows > 0 {
+			stats.NullRate = float64(c.nulls) / float64(p.rows)
+		}
+		// A column is numeric when every non-null value parsed as a number
+		stats.Numeric = c.count > 0 && c.numeric == c.count
+		if stats.Numeric {
+			stats.Min = formatNumber(c.minNum)
+			stats.Max = formatNumber(c.maxNum)
+			stats.Histogram = c.histogram.Bins()
+		} else if c.count > 0 {
+			stats.Min = c.minStr
+			stats.Max = c.maxStr
+		}
+		report.Columns[i] = stats
+	}
+	return report
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,162 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHyperLogLog_Estimate(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 200000} {
+		h := newHyperLogLog()
+		for i := 0; i < n; i++ {
+			// Every value is added twice: duplicates must not change the estimate
+			h.Add(hashString("loan-" + strconv.Itoa(i)))
+			h.Add(hashString("loan-" + strconv.Itoa(i)))
+		}
+		got := float64(h.Estimate())
+		if math.Abs(got-float64(n)) > 0.03*float64(n)+1 {
+			t.Errorf("expected about %d distinct values, got %.0f", n, got)
+		}
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	r := newReservoir(5, 1)
+	for i := 0; i < 3; i++ {
+		r.Add(strconv.Itoa(i))
+	}
+	if len(r.values) != 3 {
+		t.Fatalf("expected all 3 values while under capacity, got %v", r.values)
+	}
+	for i := 3; i < 1000; i++ {
+		r.Add(strconv.Itoa(i))
+	}
+	if len(r.values) != 5 || r.seen != 1000 {
+		t.Errorf("expected 5 of 1000 values, got %d of %d", len(r.values), r.seen)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	var total int64
+	add := func(v float64) {
+		h.Add(v)
+		total++
+	}
+	for i := 0; i < 100; i++ {
+		add(float64(i))
+	}
+	// Values far outside the initial range grow it in both directions
+	add(-5000)
+	add(1e6)
+
+	bins := h.Bins()
+	var counted int64
+	for i, b := range bins {
+		counted += b.Count
+		if b.High <= b.Low {
+			t.Errorf("bin %d has an empty range: %+v", i, b)
+		}
+		if i > 0 && b.Low < bins[i-1].High {
+			t.Errorf("bin %d overlaps the previous bin", i)
+		}
+	}
+	if counted != total {
+		t.Errorf("expected %d values in the bins, got %d", total, counted)
+	}
+	if bins[0].Low > -5000 || bins[len(bins)-1].High <= 1e6 {
+		t.Errorf("expected the bins to cover -5000 to 1e6, got %v to %v", bins[0].Low, bins[len(bins)-1].High)
+	}
+}
+
+func TestHistogram_FewValues(t *testing.T) {
+	var h histogram
+	h.Add(7)
+	h.Add(7)
+	bins := h.Bins()
+	if len(bins) != 1 || bins[0].Count != 2 {
+		t.Errorf("expected one bin with both values, got %+v", bins)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	top := newTopK(4)
+	for _, v := range strings.Split("a a a a b b b c c d e f", " ") {
+		top.Add(v)
+	}
+	got := top.Top(2)
+	if len(got) != 2 || got[0] != (ValueCount{Value: "a", Count: 4}) || got[1] != (ValueCount{Value: "b", Count: 3}) {
+		t.Errorf("expected a (4) and b (3), got %v", got)
+	}
+}
+
+func TestProfiler_Report(t *testing.T) {
+	conf := &config.ParserConfig{
+		NumberFormats: map[string]utils.NumberFormat{"annual_inc": {CurrencyPrefix: "€", GroupingSeparator: "."}},
+	}
+	p := NewProfiler(conf, []string{"id", "int_rate", "annual_inc", "grade"})
+	rows := [][]string{
+		{"1", "13.56%", "€45.000", "C"},
+		{"2", "9.17%", "€55.000", "B"},
+		{"3", "", "€100.000", "B"},
+		{"4", "6.49%", "unknown", "A", "extra"},
+		{"5", "22.45%"},
+	}
+	for _, row := range rows {
+		p.Add(row)
+	}
+	report := p.Report("loans.csv")
+
+	if report.Rows != 5 || len(report.Columns) != 5 {
+		t.Fatalf("expected 5 rows and 5 columns, got %d and %d", report.Rows, len(report.Columns))
+	}
+	intRate := report.Columns[1]
+	if !intRate.Numeric || intRate.Min != "6.49" || intRate.Max != "22.45" || intRate.Nulls != 1 || intRate.NullRate != 0.2 {
+		t.Errorf("unexpected int_rate stats: %+v", intRate)
+	}
+	annualInc := report.Columns[2]
+	if annualInc.Numeric || annualInc.Min != "unknown" || annualInc.Nulls != 1 {
+		t.Errorf("expected annual_inc to be text because of 'unknown', got %+v", annualInc)
+	}
+	grade := report.Columns[3]
+	if grade.DistinctEstimate != 3 || grade.TopValues[0] != (ValueCount{Value: "B", Count: 2}) {
+		t.Errorf("unexpected grade stats: %+v", grade)
+	}
+	extra := report.Columns[4]
+	if extra.Name != "col_4" || extra.Count != 1 || extra.Nulls != 4 {
+		t.Errorf("unexpected stats for the unnamed column: %+v", extra)
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	p := NewProfiler(&config.ParserConfig{}, []string{"id", "note"})
+	p.Add([]string{"1", "a|b"})
+	report := p.Report("loans.csv")
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Columns[1].Name != "note" {
+		t.Errorf("expected the JSON report to round-trip, got %v", err)
+	}
+
+	buf.Reset()
+	if err := report.Write(&buf, FormatMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `| 1 | note | 0.00% | 1 | text | a\|b | a\|b | a\|b (1) |`) {
+		t.Errorf("unexpected markdown:\n%s", buf.String())
+	}
+
+	if err := report.Write(&buf, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
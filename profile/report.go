@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Report formats
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// markdownTopValues is the number of top values shown per column in the Markdown table.
+const markdownTopValues = 5
+
+// CheckFormat returns an error unless format is FormatJSON or FormatMarkdown.
+func CheckFormat(format string) error {
+	if format != FormatJSON && format != FormatMarkdown {
+		return fmt.Errorf("unknown profile format %q: expected %q or %q", format, FormatJSON, FormatMarkdown)
+	}
+	return nil
+}
+
+// Write writes the report to w in the given format.
+func (r Report) Write(w io.Writer, format string) error {
+	if err := CheckFormat(format); err != nil {
+		return err
+	}
+	if format == FormatMarkdown {
+		return r.WriteMarkdown(w)
+	}
+	return r.WriteJSON(w)
+}
+
+// WriteJSON writes the full report, including samples and histograms, as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes a summary table with one line per column.
+// Samples, histograms and the full top value lists are only in the JSON report.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Profile of %s\n\n", r.File)
+	fmt.Fprintf(&b, "%d rows, %d columns. Distinct counts are estimates.\n\n", r.Rows, len(r.Columns))
+	b.WriteString("| # | Column | Null rate | Distinct | Type | Min | Max | Top values |\n")
+	b.WriteString("|---|--------|-----------|----------|------|-----|-----|------------|\n")
+	for _, c := range r.Columns {
+		kind := "text"
+		if c.Numeric {
+			kind = "number"
+		} else if c.Count == 0 {
+			kind = "empty"
+		}
+		top := make([]string, 0, markdownTopValues)
+		for _, v := range c.TopValues[:min(len(c.TopValues), markdownTopValues)] {
+			top = append(top, fmt.Sprintf("%s (%d)", markdownCell(v.Value), v.Count))
+		}
+		fmt.Fprintf(&b, "| %d | %s | %.2f%% | %d | %s | %s | %s | %s |\n",
+			c.Index, markdownCell(c.Name), c.NullRate*100, c.DistinctEstimate, kind,
+			markdownCell(c.Min), markdownCell(c.Max), strings.Join(top, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell shortens a value and escapes the characters that would break a table row.
+func markdownCell(s string) string {
+	const maxLen = 40
+	if utf8.RuneCountInString(s) > maxLen {
+		s = string([]rune(s)[:maxLen]) + "…"
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,222 @@
+package profile
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+)
+
+// hashString returns a well-mixed 64-bit hash: FNV-1a followed by the murmur3 finalizer,
+// which spreads FNV's weak high bits enough for HyperLogLog.
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// hllPrecision gives 2^14 registers per column: 16 KiB and a standard error of about 0.8%.
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct values added to it.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+func (h *hyperLogLog) Add(hash uint64) {
+	idx := hash >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Linear counting is more accurate while many registers are still empty
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// reservoir keeps a uniform random sample of the values added to it (Algorithm R).
+type reservoir struct {
+	values []string
+	seen   int64
+	rng    *rand.Rand
+}
+
+func newReservoir(size int, seed uint64) *reservoir {
+	return &reservoir{values: make([]string, 0, size), rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (r *reservoir) Add(value string) {
+	r.seen++
+	if len(r.values) < cap(r.values) {
+		r.values = append(r.values, value)
+		return
+	}
+	if i := r.rng.Int64N(r.seen); i < int64(len(r.values)) {
+		r.values[i] = value
+	}
+}
+
+// histogramBins is the number of bins in every numeric histogram.
+const histogramBins = 20
+
+// histogramSeed is the number of values buffered to choose the initial bin width.
+const histogramSeed = 64
+
+// histogram counts numeric values in a fixed number of equal-width bins.
+// The range grows by doubling the bin width and merging neighbouring bins, so counts stay exact
+// and memory stays constant without knowing the range up front.
+type histogram struct {
+	seed   []float64
+	low    float64
+	width  float64
+	counts [histogramBins]int64
+}
+
+func (h *histogram) Add(v float64) {
+	if h.width == 0 {
+		h.seed = append(h.seed, v)
+		if len(h.seed) == histogramSeed {
+			h.start()
+		}
+		return
+	}
+	h.place(v)
+}
+
+// start fixes the initial range from the buffered values and replays them.
+func (h *histogram) start() {
+	low, high := slices.Min(h.seed), slices.Max(h.seed)
+	h.low = low
+	h.width = (high - low) / histogramBins
+	if h.width == 0 {
+		h.width = 1
+	}
+	// Nudge the width so the maximum falls inside the last bin rather than on its upper edge
+	h.width = math.Nextafter(h.width, math.Inf(1))
+	for _, v := range h.seed {
+		h.place(v)
+	}
+	h.seed = nil
+}
+
+func (h *histogram) place(v float64) {
+	for v < h.low {
+		h.growLeft()
+	}
+	for v >= h.low+h.width*histogramBins {
+		h.growRight()
+	}
+	i := int((v - h.low) / h.width)
+	h.counts[min(i, histogramBins-1)]++
+}
+
+// growRight doubles the width, keeping low: old bins 2i and 2i+1 become bin i.
+func (h *histogram) growRight() {
+	var merged [histogramBins]int64
+	for i, c := range h.counts {
+		merged[i/2] += c
+	}
+	h.counts = merged
+	h.width *= 2
+}
+
+// growLeft doubles the width and moves low down by the old range: old bins 2i and 2i+1 become bin half+i.
+func (h *histogram) growLeft() {
+	var merged [histogramBins]int64
+	for i, c := range h.counts {
+		merged[histogramBins/2+i/2] += c
+	}
+	h.counts = merged
+	h.low -= h.width * histogramBins
+	h.width *= 2
+}
+
+// Bins returns the non-empty bins. Before the range is fixed every buffered value is binned from its own min and max.
+func (h *histogram) Bins() []Bin {
+	if h.width == 0 {
+		if len(h.seed) == 0 {
+			return nil
+		}
+		h.start()
+	}
+	var result []Bin
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		low := h.low + float64(i)*h.width
+		result = append(result, Bin{Low: low, High: low + h.width, Count: c})
+	}
+	return result
+}
+
+// topK tracks the most frequent values with the Space-Saving algorithm: counts are exact for values
+// that were never evicted and overestimated by at most the smallest tracked count otherwise.
+type topK struct {
+	capacity int
+	counts   map[string]int64
+}
+
+func newTopK(capacity int) *topK {
+	return &topK{capacity: capacity, counts: make(map[string]int64, capacity)}
+}
+
+func (t *topK) Add(value string) {
+	if _, ok := t.counts[value]; ok || len(t.counts) < t.capacity {
+		t.counts[value]++
+		return
+	}
+	minValue, minCount := "", int64(math.MaxInt64)
+	for v, c := range t.counts {
+		if c < minCount || (c == minCount && v < minValue) {
+			minValue, minCount = v, c
+		}
+	}
+	delete(t.counts, minValue)
+	t.counts[value] = minCount + 1
+}
+
+// Top returns up to n values by descending count, ties broken by value.
+func (t *topK) Top(n int) []ValueCount {
+	result := make([]ValueCount, 0, len(t.counts))
+	for v, c := range t.counts {
+		result = append(result, ValueCount{Value: v, Count: c})
+	}
+	slices.SortFunc(result, func(a, b ValueCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
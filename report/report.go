@@ -0,0 +1,302 @@
+// Package report builds the machine-readable summary of a validation run: what was read, what was rejected and why,
+// how fast and with how much memory, and the configuration used.
+package report
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-file-parsing/cache"
+	"go-file-parsing/config"
+	"go-file-parsing/manifest"
+	"go-file-parsing/validator"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// topColumnCount is the number of columns listed in Report.TopColumns.
+const topColumnCount = 10
+
+// heapSampleInterval is how often the heap is sampled for Report.PeakHeapBytes.
+const heapSampleInterval = 100 * time.Millisecond
+
+// Report summarizes one run.
+type Report struct {
+	RunID     string `json:"runId"`
+	InputFile string `json:"inputFile"`
+	// SHA256 is the hex checksum of the input file, empty if it could not be read
+	SHA256          string    `json:"sha256"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	// RowsRead counts the data rows; RowsRejected includes the Duplicates
+	RowsRead     int64 `json:"rowsRead"`
+	RowsAccepted int64 `json:"rowsAccepted"`
+	RowsRejected int64 `json:"rowsRejected"`
+	Duplicates   int64 `json:"duplicates"`
+	// FailuresByError counts rejected rows by the innermost error, usually a rule's sentinel error or validator.ErrDuplicate;
+	// FailuresByCode counts them by validator.ErrorCode
+	FailuresByError []Count `json:"failuresByError"`
+	FailuresByCode  []Count `json:"failuresByCode"`
+	// TopColumns are the columns checked by the rules that rejected the most rows
+	TopColumns    []Count `json:"topColumns"`
+	RowsPerSecond float64 `json:"rowsPerSecond"`
+	PeakHeapBytes uint64  `json:"peakHeapBytes"`
+	// Manifest is the outcome of verifying the file against its manifest, nil when no manifest was given
+	Manifest *ManifestResult `json:"manifest,omitempty"`
+	// AggregateFailures are the file-level rules that failed
+	AggregateFailures []string `json:"aggregateFailures,omitempty"`
+	// Error is the error that failed the run, if any
+	Error    string                `json:"error,omitempty"`
+	Config   config.ParserConfig   `json:"config"`
+	Pipeline config.PipelineConfig `json:"pipeline"`
+}
+
+// ManifestResult compares the values a manifest expects with those of the file that was read.
+// Actual.Rows is the number of rows read, 0 when verification failed before the file was parsed.
+type ManifestResult struct {
+	File string `json:"file"`
+	// Outcome is "verified" or "failed"
+	Outcome  string            `json:"outcome"`
+	Error    string            `json:"error,omitempty"`
+	Expected manifest.Manifest `json:"expected"`
+	Actual   manifest.Manifest `json:"actual"`
+}
+
+// Count is a number of rejected rows for one key, an error, an error code or a column.
+type Count struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// Run collects the report of a run in progress. Reject may be called concurrently.
+type Run struct {
+	report      Report
+	ruleColumns map[string][]string
+
+	mu       sync.Mutex
+	byError  map[string]int64
+	byCode   map[string]int64
+	byColumn map[string]int64
+
+	// sum reads the input file once for its checksum and size; Start begins it in the background.
+	sum         func() (fileSum, error)
+	stopSampler chan struct{}
+	peakHeap    chan uint64
+}
+
+// NewRunID returns an id for a run of filename started at start: the file's base name, the UTC start time
+// and a random suffix, e.g. loans.csv-20240105T101500Z-9f86d081.
+func NewRunID(filename string, start time.Time) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return filepath.Base(filename) + "-" + start.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// Start begins collecting the report of run runID over filename. The file's checksum is computed and the heap is
+// sampled in the background until Finish. ruleColumns maps each rule name to the columns it checks.
+func Start(runID, filename string, conf *config.ParserConfig, ruleColumns map[string][]string, start time.Time) *Run {
+	r := &Run{
+		report: Report{
+			RunID:     runID,
+			InputFile: filename,
+			Start:     start,
+			Config:    *conf,
+			Pipeline:  conf.Pipeline.WithDefaults(),
+		},
+		ruleColumns: ruleColumns,
+		byError:     make(map[string]int64),
+		byCode:      make(map[string]int64),
+		byColumn:    make(map[string]int64),
+		stopSampler: make(chan struct{}),
+		peakHeap:    make(chan uint64, 1),
+	}
+	r.sum = sync.OnceValues(func() (fileSum, error) { return checksum(filename) })
+	go r.sum()
+	go r.sampleHeap()
+	return r
+}
+
+// FileSum returns the hex SHA-256 and size of the input file, waiting for the read begun by Start.
+// A manifest can be verified with it without reading the file a second time.
+func (r *Run) FileSum() (string, int64, error) {
+	sum, err := r.sum()
+	return sum.sha256, sum.bytes, err
+}
+
+// Reject counts a rejected row by its error.
+func (r *Run) Reject(err error) {
+	code := validator.ErrorCode(err)
+	key := innermost(err).Error()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byError[key]++
+	r.byCode[code]++
+	for _, column := range r.ruleColumns[code] {
+		r.byColumn[column]++
+	}
+}
+
+// SetRows records the row counts of the run.
+func (r *Run) SetRows(read, accepted, rejected, duplicates int64) {
+	r.report.RowsRead = read
+	r.report.RowsAccepted = accepted
+	r.report.RowsRejected = rejected
+	r.report.Duplicates = duplicates
+}
+
+// SetPipeline records the stage sizes the run used, which differ from the config's when they were autotuned.
+func (r *Run) SetPipeline(pc config.PipelineConfig) {
+	r.report.Pipeline = pc
+}
+
+// SetAggregateFailures records the file-level rules that failed.
+func (r *Run) SetAggregateFailures(errs []*validator.AggregateError) {
+	for _, err := range errs {
+		r.report.AggregateFailures = append(r.report.AggregateFailures, err.Error())
+	}
+}
+
+// SetManifest records the verification of the file against the manifest read from file.
+// expected is the zero Manifest if the manifest could not be loaded, rows the number of rows read and verifyErr
+// the error that failed verification, if any. The file's size and checksum are filled in by Finish.
+func (r *Run) SetManifest(file string, expected manifest.Manifest, rows int64, verifyErr error) {
+	result := &ManifestResult{File: file, Outcome: "verified", Expected: expected, Actual: manifest.Manifest{Rows: rows}}
+	if verifyErr != nil {
+		result.Outcome = "failed"
+		result.Error = verifyErr.Error()
+	}
+	r.report.Manifest = result
+}
+
+// Finish stops collecting and returns the report, ending at end. runErr is the error that failed the run, if any.
+func (r *Run) Finish(end time.Time, runErr error) Report {
+	close(r.stopSampler)
+	rep := r.report
+	rep.End = end
+	rep.DurationSeconds = end.Sub(rep.Start).Seconds()
+	if rep.DurationSeconds > 0 {
+		rep.RowsPerSecond = float64(rep.RowsRead) / rep.DurationSeconds
+	}
+	if runErr != nil {
+		rep.Error = runErr.Error()
+	}
+	sum, _ := r.sum()
+	rep.SHA256 = sum.sha256
+	if rep.Manifest != nil {
+		result := *rep.Manifest
+		result.Actual.Bytes, result.Actual.SHA256 = sum.bytes, sum.sha256
+		rep.Manifest = &result
+	}
+	rep.PeakHeapBytes = <-r.peakHeap
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep.FailuresByError = sortedCounts(r.byError)
+	rep.FailuresByCode = sortedCounts(r.byCode)
+	rep.TopColumns = sortedCounts(r.byColumn)
+	if len(rep.TopColumns) > topColumnCount {
+		rep.TopColumns = rep.TopColumns[:topColumnCount]
+	}
+	return rep
+}
+
+// Write writes the report to filename as indented JSON.
+func (rep Report) Write(filename string) error {
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0o644)
+}
+
+// Key is the cache key the report is stored under.
+func (rep Report) Key() string {
+	return "report:" + rep.RunID
+}
+
+// Store writes the report to c as JSON under Key.
+func (rep Report) Store(ctx context.Context, c cache.DistributedCache) error {
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, rep.Key(), string(data))
+}
+
+// sampleHeap records the largest heap seen until stopSampler is closed.
+func (r *Run) sampleHeap() {
+	heap := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	var peak uint64
+	ticker := time.NewTicker(heapSampleInterval)
+	defer ticker.Stop()
+	for {
+		metrics.Read(heap)
+		if heap[0].Value.Kind() == metrics.KindUint64 {
+			peak = max(peak, heap[0].Value.Uint64())
+		}
+		select {
+		case <-r.stopSampler:
+			r.peakHeap <- peak
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// innermost returns the error at the end of err's chain, which is the sentinel for errors wrapped with %w.
+// A joined error ends the chain.
+func innermost(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// sortedCounts returns counts with the largest first, ties in key order.
+func sortedCounts(counts map[string]int64) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		sorted = append(sorted, Count{Key: key, Count: count})
+	}
+	slices.SortFunc(sorted, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return sorted
+}
+
+// fileSum is the checksum and size of the input file, empty if it could not be read.
+type fileSum struct {
+	sha256 string
+	bytes  int64
+}
+
+func checksum(filename string) (fileSum, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fileSum{}, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	n, err := io.Copy(hash, file)
+	if err != nil {
+		return fileSum{}, err
+	}
+	return fileSum{sha256: hex.EncodeToString(hash.Sum(nil)), bytes: n}, nil
+}
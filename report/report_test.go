@@ -0,0 +1,162 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-file-parsing/config"
+	"go-file-parsing/manifest"
+	"go-file-parsing/validator"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	errNotNumber  = errors.New("loan amount is not a number")
+	errOutOfRange = errors.New("term is not between 12 and 72 months")
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "loans.csv")
+	if err := os.WriteFile(input, []byte("test"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf := &config.ParserConfig{Delimiter: ",", HasHeader: true}
+	ruleColumns := map[string][]string{
+		"loan_amount": {"loan_amnt", "funded_amnt"},
+		"term":        {"term"},
+	}
+	start := time.Date(2024, 1, 5, 10, 15, 0, 0, time.UTC)
+	run := Start("run-1", input, conf, ruleColumns, start)
+
+	run.Reject(&validator.RuleError{Rule: "loan_amount", Err: errNotNumber})
+	run.Reject(&validator.RuleError{Rule: "loan_amount", Err: errNotNumber})
+	run.Reject(&validator.RuleError{Rule: "term", Err: fmt.Errorf("%w: got 84", errOutOfRange)})
+	run.Reject(&validator.DuplicateError{Key: "id", Value: "1", Row: 3, First: validator.Occurrence{Row: 1}})
+	run.Reject(&validator.DuplicateError{Key: "member_id", Value: "m1", Row: 5, First: validator.Occurrence{Row: 2}})
+	run.SetRows(10, 5, 5, 2)
+	if sum, size, err := run.FileSum(); err != nil || size != 4 || sum != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("unexpected file sum %s, %d bytes: %v", sum, size, err)
+	}
+	rep := run.Finish(start.Add(2*time.Second), errors.New("manifest verification failed"))
+
+	if rep.RunID != "run-1" || rep.InputFile != input {
+		t.Errorf("unexpected run id or input file: %s, %s", rep.RunID, rep.InputFile)
+	}
+	// sha256 of "test"
+	if want := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"; rep.SHA256 != want {
+		t.Errorf("expected checksum %s, got %s", want, rep.SHA256)
+	}
+	if rep.RowsRead != 10 || rep.RowsAccepted != 5 || rep.RowsRejected != 5 || rep.Duplicates != 2 {
+		t.Errorf("unexpected row counts: %+v", rep)
+	}
+	if rep.DurationSeconds != 2 || rep.RowsPerSecond != 5 {
+		t.Errorf("expected 2s at 5 rows/s, got %vs at %v rows/s", rep.DurationSeconds, rep.RowsPerSecond)
+	}
+	if rep.Error != "manifest verification failed" {
+		t.Errorf("expected the run error, got %q", rep.Error)
+	}
+
+	wantByError := []Count{
+		{Key: validator.ErrDuplicate.Error(), Count: 2},
+		{Key: errNotNumber.Error(), Count: 2},
+		{Key: errOutOfRange.Error(), Count: 1},
+	}
+	if !reflect.DeepEqual(rep.FailuresByError, wantByError) {
+		t.Errorf("expected failures by error %v, got %v", wantByError, rep.FailuresByError)
+	}
+	wantByCode := []Count{{Key: "duplicate", Count: 2}, {Key: "loan_amount", Count: 2}, {Key: "term", Count: 1}}
+	if !reflect.DeepEqual(rep.FailuresByCode, wantByCode) {
+		t.Errorf("expected failures by code %v, got %v", wantByCode, rep.FailuresByCode)
+	}
+	wantColumns := []Count{{Key: "funded_amnt", Count: 2}, {Key: "loan_amnt", Count: 2}, {Key: "term", Count: 1}}
+	if !reflect.DeepEqual(rep.TopColumns, wantColumns) {
+		t.Errorf("expected top columns %v, got %v", wantColumns, rep.TopColumns)
+	}
+}
+
+func TestRun_Manifest(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "loans.csv")
+	if err := os.WriteFile(input, []byte("test"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2024, 1, 5, 10, 15, 0, 0, time.UTC)
+	run := Start("run-1", input, &config.ParserConfig{Delimiter: ","}, nil, start)
+	expected := manifest.Manifest{Rows: 12, Bytes: 4}
+	run.SetManifest("loans.manifest", expected, 10, fmt.Errorf("%w: read 10, expected 12", manifest.ErrRowsMismatch))
+	rep := run.Finish(start.Add(time.Second), nil)
+
+	want := &ManifestResult{
+		File:     "loans.manifest",
+		Outcome:  "failed",
+		Error:    "row count does not match the manifest: read 10, expected 12",
+		Expected: expected,
+		Actual:   manifest.Manifest{Rows: 10, Bytes: 4, SHA256: rep.SHA256},
+	}
+	if !reflect.DeepEqual(rep.Manifest, want) {
+		t.Errorf("expected manifest %+v, got %+v", want, rep.Manifest)
+	}
+}
+
+func TestReport_WriteAndStore(t *testing.T) {
+	rep := Report{RunID: "run-1", InputFile: "loans.csv", RowsRead: 3, Config: config.ParserConfig{Delimiter: ","}}
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	if err := rep.Write(filename); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var written map[string]any
+	if err = json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written["runId"] != "run-1" || written["rowsRead"] != float64(3) {
+		t.Errorf("unexpected report file: %s", data)
+	}
+
+	c := &setCache{}
+	if err = rep.Store(context.Background(), c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.key != "report:run-1" || !strings.Contains(c.value, `"inputFile":"loans.csv"`) {
+		t.Errorf("unexpected cache write %s = %s", c.key, c.value)
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	start := time.Date(2024, 1, 5, 10, 15, 0, 0, time.UTC)
+	id := NewRunID("data/loans.csv", start)
+	if !strings.HasPrefix(id, "loans.csv-20240105T101500Z-") || len(id) != len("loans.csv-20240105T101500Z-")+8 {
+		t.Errorf("unexpected run id %s", id)
+	}
+	if other := NewRunID("data/loans.csv", start); other == id {
+		t.Errorf("expected runs started at the same time to get different ids, got %s twice", id)
+	}
+}
+
+// setCache records the last Set.
+type setCache struct {
+	key, value string
+}
+
+func (c *setCache) Get(context.Context, string) (string, error) { return "", errors.New("missing key") }
+func (c *setCache) Set(_ context.Context, key, value string) error {
+	c.key, c.value = key, value
+	return nil
+}
+func (c *setCache) SetField(context.Context, string, string, string) error     { return nil }
+func (c *setCache) SetIfMissing(context.Context, string, string) (bool, error) { return true, nil }
+func (c *setCache) GetField(context.Context, string, string) (string, error) {
+	return "", errors.New("missing key")
+}
+func (c *setCache) Delete(context.Context, string) error { return nil }
+func (c *setCache) Close()                               {}
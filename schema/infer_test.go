@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"encoding/json"
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func inferColumns(header []string, rows ...[]string) []config.ColumnSchema {
+	inf := NewInferrer(header)
+	for _, row := range rows {
+		inf.Add(row)
+	}
+	return inf.Columns()
+}
+
+func TestInferTypes(t *testing.T) {
+	header := []string{"id", "loan_amnt", "int_rate", "annual_inc", "issue_d", "term", "emp_title", "desc"}
+	rows := [][]string{
+		{"1", "3600.0", "13.56%", "$55,000", "Dec-2015", "36 months", "leadman", ""},
+		{"2", "24700.0", "11.99%", "$65,000.50", "Nov-2015", "60 months", "Engineer", ""},
+		{"3", "20000.0", "10.78%", "$63,000", "Jan-2016", "36 months", "truck driver", ""},
+	}
+	columns := inferColumns(header, rows...)
+
+	want := []struct {
+		typ        string
+		nullable   bool
+		dateLayout string
+		values     []string
+	}{
+		{typ: config.TypeInt},
+		{typ: config.TypeInt},
+		{typ: config.TypePercent},
+		{typ: config.TypeDecimal},
+		{typ: config.TypeDate, dateLayout: "Jan-2006"},
+		{typ: config.TypeEnum, values: []string{"36 months", "60 months"}},
+		{typ: config.TypeEnum, values: []string{"Engineer", "leadman", "truck driver"}},
+		{typ: config.TypeText, nullable: true},
+	}
+	for i, w := range want {
+		c := columns[i]
+		if c.Name != header[i] || c.Type != w.typ || c.Nullable != w.nullable || c.DateLayout != w.dateLayout || !reflect.DeepEqual(c.Values, w.values) {
+			t.Errorf("column %s: expected %+v, got %+v", header[i], w, c)
+		}
+	}
+	if columns[2].Min != "10.78%" || columns[2].Max != "13.56%" {
+		t.Errorf("expected int_rate range 10.78%% to 13.56%%, got %s to %s", columns[2].Min, columns[2].Max)
+	}
+	if columns[0].SuggestedValidators[0] != "required" || columns[0].SuggestedValidators[1] != "range 1 to 3" {
+		t.Errorf("unexpected id suggestions: %v", columns[0].SuggestedValidators)
+	}
+	if columns[7].SuggestedValidators != nil || !reflect.DeepEqual(columns[7].Notes, []string{"always empty in the sample"}) {
+		t.Errorf("expected an empty column to get a note and no suggestions, got %+v", columns[7])
+	}
+}
+
+func TestInferText(t *testing.T) {
+	var rows [][]string
+	for i := 0; i <= enumMaxValues; i++ {
+		rows = append(rows, []string{"title " + strconv.Itoa(i)})
+	}
+	rows = append(rows, []string{""})
+	c := inferColumns([]string{"title"}, rows...)[0]
+	if c.Type != config.TypeText || !c.Nullable || c.MaxLength != 8 || c.Values != nil {
+		t.Errorf("expected nullable text with max length 8, got %+v", c)
+	}
+}
+
+func TestInferShortAndLongRows(t *testing.T) {
+	columns := inferColumns([]string{"id", "grade"}, []string{"1"}, []string{"2", "B", "x"})
+	if len(columns) != 3 || columns[2].Name != "col_2" {
+		t.Fatalf("expected an unnamed third column, got %+v", columns)
+	}
+	if !columns[1].Nullable || !columns[2].Nullable {
+		t.Errorf("expected columns missing from a row to be nullable, got %+v", columns)
+	}
+}
+
+func TestStarterConfig(t *testing.T) {
+	inf := NewInferrer([]string{"id", "int_rate", "annual_inc", "issue_d"})
+	inf.Add([]string{"1", "13.56%", "$55,000", "2015-12-01"})
+	inf.Add([]string{"2", "9.17%", "42000", "2015-11-01"})
+	conf := inf.StarterConfig(&config.ParserConfig{Delimiter: ",", HasHeader: true, Encoding: config.EncodingLatin1})
+
+	if conf.ExpectedColumns != 4 || conf.Delimiter != "," || !conf.HasHeader || conf.Encoding != config.EncodingLatin1 || len(conf.Columns) != 4 {
+		t.Errorf("unexpected starter config: %+v", conf)
+	}
+	wantFormats := map[string]utils.NumberFormat{
+		"int_rate":   {PercentSuffix: true},
+		"annual_inc": {CurrencyPrefix: "$", GroupingSeparator: ","},
+	}
+	if !reflect.DeepEqual(conf.NumberFormats, wantFormats) {
+		t.Errorf("expected number formats %v, got %v", wantFormats, conf.NumberFormats)
+	}
+	if !reflect.DeepEqual(conf.DateLayouts, map[string][]string{"issue_d": {utils.ISODateLayout}}) {
+		t.Errorf("unexpected date layouts: %v", conf.DateLayouts)
+	}
+
+	// The written config loads back as a parser config
+	data, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := config.LoadParserConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading starter config: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Columns, conf.Columns) {
+		t.Errorf("expected columns to round-trip")
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// ISODateLayout is the layout used for dates with a day component.
+	ISODateLayout = "2006-01-02"
+	// ISOMonthLayout is the layout used for month-precision dates such as earliest_cr_line.
+	ISOMonthLayout = "2006-01"
+)
+
+var ErrInvalidDate = errors.New("date does not match any layout")
+
+// ParseDate parses s with the first layout in layouts that accepts it.
+func ParseDate(s string, layouts []string) (time.Time, error) {
+	t, _, err := parseDateLayout(s, layouts)
+	return t, err
+}
+
+// NormalizeDate parses s with layouts and formats it as ISO 8601.
+// Month-precision layouts such as "Jan-2006" produce "YYYY-MM"; layouts with a day produce "YYYY-MM-DD".
+func NormalizeDate(s string, layouts []string) (time.Time, string, error) {
+	t, layout, err := parseDateLayout(s, layouts)
+	if err != nil {
+		return t, "", err
+	}
+	if hasDay(layout) {
+		return t, t.Format(ISODateLayout), nil
+	}
+	return t, t.Format(ISOMonthLayout), nil
+}
+
+func parseDateLayout(s string, layouts []string) (time.Time, string, error) {
+	s = TrimIfNeeded(s)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", ErrInvalidDate
+}
+
+// hasDay reports whether a layout includes a day-of-month element ("02", "_2" or "2").
+func hasDay(layout string) bool {
+	// Strip the year and the numeric month first so their digits are not mistaken for a day.
+	l := strings.ReplaceAll(layout, "2006", "")
+	l = strings.ReplaceAll(l, "01", "")
+	return strings.Contains(l, "2")
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	layouts := []string{"Jan-2006", "2006-01", "01/02/2006"}
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "dataset month format",
+			input:    "Aug-2003",
+			expected: "2003-08",
+		},
+		{
+			name:     "dataset month format with whitespace",
+			input:    " Dec-2015 ",
+			expected: "2015-12",
+		},
+		{
+			name:     "ISO month",
+			input:    "2003-08",
+			expected: "2003-08",
+		},
+		{
+			name:     "layout with day",
+			input:    "08/15/2003",
+			expected: "2003-08-15",
+		},
+		{
+			name:    "unknown layout",
+			input:   "2003.08",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, iso, err := NormalizeDate(tc.input, layouts)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Errorf("expected ErrInvalidDate, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if iso != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, iso)
+			}
+		})
+	}
+}
+
+func TestParseDate_LayoutOrder(t *testing.T) {
+	// "01-2006" and "02-2006" read the same input differently; the first matching layout wins
+	d, err := ParseDate("03-2010", []string{"01-2006", "02-2006"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Month() != 3 || d.Day() != 1 {
+		t.Errorf("expected March 1st, got %s", d)
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale is the number of fractional digits a Decimal keeps.
+// Four digits is enough for cents as well as the ratio columns (dti, int_rate),
+// which the dataset reports with at most two decimal places.
+const DecimalScale = 4
+
+// decimalFactor is 10^DecimalScale, the number of units in 1.
+const decimalFactor = 10000
+
+var (
+	ErrInvalidDecimal  = errors.New("invalid decimal")
+	ErrDecimalOverflow = errors.New("decimal out of range")
+)
+
+// Decimal is a fixed-point number stored as a count of 10^-DecimalScale units.
+// Equal values always have equal representations, so Decimals can be compared with ==.
+type Decimal int64
+
+// NewDecimal returns the Decimal for a whole number.
+func NewDecimal(i int64) Decimal {
+	return Decimal(i * decimalFactor)
+}
+
+// ParseDecimal parses a plain decimal string such as "3600.0", "-12.5" or "4421.723916800001".
+//
+// Precision rules:
+//   - an optional leading '+' or '-' is allowed, followed by digits with at most one '.'
+//   - at least one digit is required; ".5" and "5." are accepted
+//   - exponents, grouping separators and surrounding whitespace are rejected
+//   - digits beyond DecimalScale are rounded half away from zero ("0.00005" becomes "0.0001")
+func ParseDecimal(s string) (Decimal, error) {
+	if s == "" {
+		return 0, ErrInvalidDecimal
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidDecimal
+	}
+	if hasPoint && strings.Contains(fracPart, ".") {
+		return 0, ErrInvalidDecimal
+	}
+
+	var units int64
+	for i := 0; i < len(intPart); i++ {
+		c := intPart[i]
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidDecimal
+		}
+		if units > (math.MaxInt64-int64(c-'0'))/10 {
+			return 0, ErrDecimalOverflow
+		}
+		units = units*10 + int64(c-'0')
+	}
+	if units > math.MaxInt64/decimalFactor {
+		return 0, ErrDecimalOverflow
+	}
+	units *= decimalFactor
+
+	var frac int64
+	scale := int64(decimalFactor)
+	roundUp := false
+	for i := 0; i < len(fracPart); i++ {
+		c := fracPart[i]
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidDecimal
+		}
+		switch {
+		case i < DecimalScale:
+			scale /= 10
+			frac += int64(c-'0') * scale
+		case i == DecimalScale:
+			// Only the first dropped digit decides the rounding direction.
+			roundUp = c >= '5'
+		}
+	}
+	if roundUp {
+		frac++
+	}
+	if units > math.MaxInt64-frac {
+		return 0, ErrDecimalOverflow
+	}
+	units += frac
+
+	if neg {
+		units = -units
+	}
+	return Decimal(units), nil
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// Cmp compares d and o and returns -1, 0 or 1.
+func (d Decimal) Cmp(o Decimal) int {
+	// Compared directly, since d-o overflows for operands far apart
+	return cmp.Compare(d, o)
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Round rounds d to the given number of fractional digits, half away from zero.
+func (d Decimal) Round(places int) Decimal {
+	if places >= DecimalScale {
+		return d
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(1)
+	for i := places; i < DecimalScale; i++ {
+		step *= 10
+	}
+	units := int64(d.Abs())
+	rem := units % step
+	units -= rem
+	if rem*2 >= step {
+		units += step
+	}
+	if d < 0 {
+		units = -units
+	}
+	return Decimal(units)
+}
+
+// IntPart returns the whole-number part of d, truncated toward zero.
+func (d Decimal) IntPart() int64 {
+	return int64(d) / decimalFactor
+}
+
+// Float64 returns the nearest float64 to d. It is meant for approximate
+// calculations such as amortization, never for comparisons of stored values.
+func (d Decimal) Float64() float64 {
+	return float64(d) / decimalFactor
+}
+
+// String formats d without trailing fractional zeros, e.g. "3600" or "13.99".
+func (d Decimal) String() string {
+	units := int64(d)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	whole := strconv.FormatInt(units/decimalFactor, 10)
+	frac := units % decimalFactor
+	if frac == 0 {
+		return sign + whole
+	}
+	fracStr := strconv.FormatInt(frac+decimalFactor, 10)[1:]
+	return sign + whole + "." + strings.TrimRight(fracStr, "0")
+}
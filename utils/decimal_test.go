@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "whole number",
+			input:    "3600",
+			expected: "3600",
+		},
+		{
+			name:     "dataset trailing .0",
+			input:    "3600.0",
+			expected: "3600",
+		},
+		{
+			name:     "cents",
+			input:    "1000.50",
+			expected: "1000.5",
+		},
+		{
+			name:     "ratio with one decimal",
+			input:    "18.7",
+			expected: "18.7",
+		},
+		{
+			name:     "interest rate",
+			input:    "13.99",
+			expected: "13.99",
+		},
+		{
+			name:     "float artifact is rounded to scale",
+			input:    "4421.723916800001",
+			expected: "4421.7239",
+		},
+		{
+			name:     "half rounds away from zero",
+			input:    "0.00005",
+			expected: "0.0001",
+		},
+		{
+			name:     "below half rounds down",
+			input:    "0.00004999",
+			expected: "0",
+		},
+		{
+			name:     "rounding carries into whole part",
+			input:    "9.99999",
+			expected: "10",
+		},
+		{
+			name:     "negative",
+			input:    "-100.25",
+			expected: "-100.25",
+		},
+		{
+			name:     "negative half rounds away from zero",
+			input:    "-0.00005",
+			expected: "-0.0001",
+		},
+		{
+			name:     "explicit plus sign",
+			input:    "+5",
+			expected: "5",
+		},
+		{
+			name:     "leading zero",
+			input:    "05",
+			expected: "5",
+		},
+		{
+			name:     "leading point",
+			input:    ".5",
+			expected: "0.5",
+		},
+		{
+			name:     "trailing point",
+			input:    "5.",
+			expected: "5",
+		},
+		{
+			name:  "empty string",
+			input: "",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "sign only",
+			input: "-",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "point only",
+			input: ".",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "letters",
+			input: "abc",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "two points",
+			input: "1.2.3",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "exponent",
+			input: "1e5",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "grouping separator",
+			input: "45,000",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "surrounding whitespace",
+			input: " 13.56",
+			err:   ErrInvalidDecimal,
+		},
+		{
+			name:  "overflow",
+			input: "99999999999999999999",
+			err:   ErrDecimalOverflow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := ParseDecimal(tc.input)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.String() != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, d.String())
+			}
+		})
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	a, _ := ParseDecimal("20.0")
+	b, _ := ParseDecimal("20.01")
+	if a.Cmp(NewDecimal(20)) != 0 {
+		t.Errorf("expected 20.0 to equal 20")
+	}
+	if a.Cmp(b) != -1 {
+		t.Errorf("expected 20.0 to be less than 20.01")
+	}
+	if b.Cmp(a) != 1 {
+		t.Errorf("expected 20.01 to be greater than 20.0")
+	}
+	// The difference of these overflows an int64
+	low, high := Decimal(math.MinInt64+1), Decimal(math.MaxInt64)
+	if low.Cmp(high) != -1 || high.Cmp(low) != 1 {
+		t.Errorf("expected operands far apart to compare by value")
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	testCases := []struct {
+		input    string
+		places   int
+		expected string
+	}{
+		{input: "123.445", places: 2, expected: "123.45"},
+		{input: "123.444", places: 2, expected: "123.44"},
+		{input: "-123.445", places: 2, expected: "-123.45"},
+		{input: "0.5", places: 0, expected: "1"},
+		{input: "2.4999", places: 0, expected: "2"},
+		{input: "1.2345", places: 6, expected: "1.2345"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			d, err := ParseDecimal(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := d.Round(tc.places).String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecimalIntPart(t *testing.T) {
+	d, _ := ParseDecimal("-12.75")
+	if d.IntPart() != -12 {
+		t.Errorf("expected -12, got %d", d.IntPart())
+	}
+	if d.Float64() != -12.75 {
+		t.Errorf("expected -12.75, got %v", d.Float64())
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import "strings"
+
+// NumberFormat describes how a column writes its numbers, so values such as
+// " 13.56%", "$1,000.50" or "1.234,5" can be normalized to the plain form ParseDecimal accepts.
+// The zero value accepts plain decimals only.
+type NumberFormat struct {
+	// PercentSuffix allows a trailing "%" (the value is kept in percent, " 13.56%" becomes 13.56).
+	PercentSuffix bool `json:",omitempty"`
+	// CurrencyPrefix is a symbol allowed before the digits, after any sign, e.g. "$".
+	CurrencyPrefix string `json:",omitempty"`
+	// GroupingSeparator separates groups of three digits in the whole part, e.g. "," for "45,000".
+	// It is only removed when every group after the first has three digits, so "1,0,0" is rejected.
+	GroupingSeparator string `json:",omitempty"`
+	// DecimalComma treats "," as the decimal point, e.g. "1.234,5" with "." grouping.
+	DecimalComma bool `json:",omitempty"`
+}
+
+// Normalize strips the decorations allowed by f and returns a string for ParseDecimal.
+// Surrounding whitespace and a pair of surrounding double quotes are always removed.
+// Anything f does not allow is left in place so that ParseDecimal rejects it.
+func (f NumberFormat) Normalize(s string) string {
+	s = TrimIfNeeded(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = TrimIfNeeded(s[1 : len(s)-1])
+	}
+
+	if f.PercentSuffix && strings.HasSuffix(s, "%") {
+		s = TrimIfNeeded(s[:len(s)-1])
+	}
+
+	sign := ""
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		sign = s[:1]
+		s = s[1:]
+	}
+	if f.CurrencyPrefix != "" && strings.HasPrefix(s, f.CurrencyPrefix) {
+		s = TrimIfNeeded(s[len(f.CurrencyPrefix):])
+		// A sign may also follow the symbol, as in "$-100".
+		if sign == "" && len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+			sign = s[:1]
+			s = s[1:]
+		}
+	}
+
+	if f.GroupingSeparator != "" {
+		point := byte('.')
+		if f.DecimalComma {
+			point = ','
+		}
+		s = stripGrouping(s, f.GroupingSeparator, point)
+	}
+	if f.DecimalComma {
+		// Swap the separators so a stray "." is left as "," and rejected by ParseDecimal.
+		s = strings.Map(func(r rune) rune {
+			switch r {
+			case ',':
+				return '.'
+			case '.':
+				return ','
+			}
+			return r
+		}, s)
+	}
+	return sign + s
+}
+
+// stripGrouping removes sep from the whole part of s, before point, when it separates groups of three digits.
+// Otherwise s is returned unchanged for ParseDecimal to reject.
+func stripGrouping(s, sep string, point byte) string {
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, point); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+	if !strings.Contains(whole, sep) || strings.Contains(frac, sep) {
+		return s
+	}
+	groups := strings.Split(whole, sep)
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return s
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return s
+		}
+	}
+	return strings.Join(groups, "") + frac
+}
+
+// ParseDecimal normalizes s according to f and parses the result.
+func (f NumberFormat) ParseDecimal(s string) (Decimal, error) {
+	return ParseDecimal(f.Normalize(s))
+}
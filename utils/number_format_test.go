@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestNumberFormatParseDecimal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   NumberFormat
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "plain format accepts plain decimal",
+			format:   NumberFormat{},
+			input:    " 3600.0 ",
+			expected: "3600",
+		},
+		{
+			name:    "plain format rejects percent",
+			format:  NumberFormat{},
+			input:   "13.56%",
+			wantErr: true,
+		},
+		{
+			name:     "percent suffix with leading space",
+			format:   NumberFormat{PercentSuffix: true},
+			input:    " 13.56%",
+			expected: "13.56",
+		},
+		{
+			name:     "percent suffix is optional",
+			format:   NumberFormat{PercentSuffix: true},
+			input:    "29.7",
+			expected: "29.7",
+		},
+		{
+			name:     "grouped income",
+			format:   NumberFormat{GroupingSeparator: ","},
+			input:    "45,000",
+			expected: "45000",
+		},
+		{
+			name:     "grouped millions with cents",
+			format:   NumberFormat{GroupingSeparator: ","},
+			input:    "1,234,567.25",
+			expected: "1234567.25",
+		},
+		{
+			name:    "grouping separator between single digits",
+			format:  NumberFormat{GroupingSeparator: ","},
+			input:   "1,0,0",
+			wantErr: true,
+		},
+		{
+			name:    "group of two digits",
+			format:  NumberFormat{GroupingSeparator: ","},
+			input:   "45,00",
+			wantErr: true,
+		},
+		{
+			name:    "leading grouping separator",
+			format:  NumberFormat{GroupingSeparator: ","},
+			input:   ",450",
+			wantErr: true,
+		},
+		{
+			name:    "grouping separator in the fraction",
+			format:  NumberFormat{GroupingSeparator: ","},
+			input:   "1,000.000,5",
+			wantErr: true,
+		},
+		{
+			name:    "decimal comma with a short group",
+			format:  NumberFormat{GroupingSeparator: ".", DecimalComma: true},
+			input:   "1.23,5",
+			wantErr: true,
+		},
+		{
+			name:    "grouping not allowed",
+			format:  NumberFormat{},
+			input:   "45,000",
+			wantErr: true,
+		},
+		{
+			name:     "currency prefix",
+			format:   NumberFormat{CurrencyPrefix: "$", GroupingSeparator: ","},
+			input:    "$1,000.50",
+			expected: "1000.5",
+		},
+		{
+			name:     "sign before currency",
+			format:   NumberFormat{CurrencyPrefix: "$"},
+			input:    "-$100",
+			expected: "-100",
+		},
+		{
+			name:     "sign after currency",
+			format:   NumberFormat{CurrencyPrefix: "$"},
+			input:    "$-100",
+			expected: "-100",
+		},
+		{
+			name:    "unexpected currency",
+			format:  NumberFormat{CurrencyPrefix: "$"},
+			input:   "€100",
+			wantErr: true,
+		},
+		{
+			name:     "decimal comma with dot grouping",
+			format:   NumberFormat{GroupingSeparator: ".", DecimalComma: true},
+			input:    "1.234,5",
+			expected: "1234.5",
+		},
+		{
+			name:    "decimal comma rejects decimal point",
+			format:  NumberFormat{DecimalComma: true},
+			input:   "13.56",
+			wantErr: true,
+		},
+		{
+			name:     "decimal comma percent",
+			format:   NumberFormat{PercentSuffix: true, DecimalComma: true},
+			input:    "13,56 %",
+			expected: "13.56",
+		},
+		{
+			name:    "empty value",
+			format:  NumberFormat{PercentSuffix: true},
+			input:   " %",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := tc.format.ParseDecimal(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got %s", tc.input, d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.String() != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, d.String())
+			}
+		})
+	}
+}
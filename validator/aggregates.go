@@ -0,0 +1,83 @@
+package validator
+
+import "fmt"
+
+// FileStats are the run totals passed to aggregate rules once the whole file has been read.
+type FileStats struct {
+	// Rows is the number of data rows read, excluding the header.
+	Rows int64
+	// ValidRows is the number of rows that passed every validator.
+	ValidRows int64
+}
+
+// Aggregate accumulates values from every row that passes validation and checks them after the file is read.
+// Add is called concurrently by the row validators, so implementations must guard their state.
+// Remove undoes Add for a row that is dropped after it was accepted, such as a duplicate.
+type Aggregate interface {
+	Add(vCtx *RowValidatorContext, cols []string, record map[string]string)
+	Remove(vCtx *RowValidatorContext, cols []string, record map[string]string)
+	Check(stats FileStats) error
+}
+
+// AggregateRule is a named file-level validation.
+type AggregateRule struct {
+	Name      string
+	Aggregate Aggregate
+}
+
+// AggregateError reports a violated aggregate rule.
+type AggregateError struct {
+	Rule string
+	Err  error
+}
+
+func (e *AggregateError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Rule, e.Err)
+}
+
+func (e *AggregateError) Unwrap() error {
+	return e.Err
+}
+
+// Accumulate returns a Derivation that adds each valid row to the aggregates.
+// Register it after any derivations whose fields the aggregates read.
+func Accumulate(aggregateRules []AggregateRule) Derivation {
+	return func(vCtx *RowValidatorContext, cols []string, record map[string]string) {
+		for _, rule := range aggregateRules {
+			rule.Aggregate.Add(vCtx, cols, record)
+		}
+	}
+}
+
+// Removing returns aggregateRules with Add and Remove swapped, so validating a row with Accumulate(Removing(rules))
+// takes it back out of the aggregates.
+func Removing(aggregateRules []AggregateRule) []AggregateRule {
+	removing := make([]AggregateRule, len(aggregateRules))
+	for i, rule := range aggregateRules {
+		removing[i] = AggregateRule{Name: rule.Name, Aggregate: removal{rule.Aggregate}}
+	}
+	return removing
+}
+
+type removal struct {
+	Aggregate
+}
+
+func (r removal) Add(vCtx *RowValidatorContext, cols []string, record map[string]string) {
+	r.Aggregate.Remove(vCtx, cols, record)
+}
+
+func (r removal) Remove(vCtx *RowValidatorContext, cols []string, record map[string]string) {
+	r.Aggregate.Add(vCtx, cols, record)
+}
+
+// CheckAggregates runs every aggregate rule and returns an *AggregateError for each violated rule.
+func CheckAggregates(aggregateRules []AggregateRule, stats FileStats) []*AggregateError {
+	var errs []*AggregateError
+	for _, rule := range aggregateRules {
+		if err := rule.Aggregate.Check(stats); err != nil {
+			errs = append(errs, &AggregateError{Rule: rule.Name, Err: err})
+		}
+	}
+	return errs
+}
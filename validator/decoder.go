@@ -0,0 +1,260 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-file-parsing/config"
+	"go-file-parsing/utils"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidEscape = errors.New("invalid escape sequence")
+	ErrMalformedJSON = errors.New("malformed JSON line")
+	ErrNotLineBased  = errors.New("format is not read line by line")
+	// ErrInvalidEncoding is returned for a line that is not valid UTF-8 after transcoding.
+	ErrInvalidEncoding = errors.New("invalid byte sequence")
+)
+
+// RecordDecoder splits one line of input into the columns passed to every ColValidator.
+type RecordDecoder interface {
+	Decode(line string) ([]string, error)
+}
+
+// NewRecordDecoder returns the decoder for conf.Format.
+// It returns ErrNotLineBased for FormatXLSX, whose rows are read already split into columns.
+func NewRecordDecoder(conf *config.ParserConfig) (RecordDecoder, error) {
+	if err := conf.CheckFormat(); err != nil {
+		return nil, err
+	}
+	switch conf.Format {
+	case config.FormatXLSX:
+		return nil, fmt.Errorf("%w: %s", ErrNotLineBased, conf.Format)
+	case config.FormatTSV:
+		return TSVDecoder{}, nil
+	case config.FormatFixedWidth:
+		return FixedWidthDecoder{Fields: conf.FixedWidthFields}, nil
+	case config.FormatJSONL:
+		return NewJSONLDecoder(conf.FieldNames()), nil
+	}
+	return DelimitedDecoder{Delimiter: conf.Delimiter}, nil
+}
+
+// DecodeLine splits line with d after checking that it is valid UTF-8,
+// so undecodable bytes are reported rather than reaching a validator as mojibake.
+func DecodeLine(d RecordDecoder, line string) ([]string, error) {
+	if !utf8.ValidString(line) {
+		return nil, fmt.Errorf("%w at byte %d of the line", ErrInvalidEncoding, invalidOffset(line))
+	}
+	return d.Decode(line)
+}
+
+// invalidOffset returns the byte offset of the first invalid UTF-8 sequence in s.
+func invalidOffset(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// DelimitedDecoder splits on Delimiter and trims the whitespace around each column.
+// Values may be quoted as in RFC 4180, so a quoted value can hold the delimiter and "" inside it stands for one quote.
+// A stray quote in an unquoted value is kept. Quoted values cannot span lines, since input is read line by line.
+// A Delimiter longer than one character is split on as is, without quoting.
+type DelimitedDecoder struct {
+	Delimiter string
+}
+
+func (d DelimitedDecoder) Decode(line string) ([]string, error) {
+	comma, size := utf8.DecodeRuneInString(d.Delimiter)
+	// Most lines have no quotes and are split directly, which is much faster than the csv reader
+	if size == 0 || size != len(d.Delimiter) || !strings.Contains(line, `"`) {
+		return PreprocessColumns(strings.Split(line, d.Delimiter)), nil
+	}
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = comma
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	// Leading space before an opening quote is allowed, unless the delimiter is itself white space
+	r.TrimLeadingSpace = !unicode.IsSpace(comma)
+	cols, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	return PreprocessColumns(cols), nil
+}
+
+// TSVDecoder splits on tabs and decodes the backslash escapes used by database TSV exports:
+// \t, \n, \r and \\ stand for themselves and \N for a null, which becomes an empty column.
+// Whitespace around each column is trimmed, as for delimited input.
+type TSVDecoder struct{}
+
+func (TSVDecoder) Decode(line string) ([]string, error) {
+	cols := strings.Split(line, "\t")
+	for i, col := range cols {
+		if !strings.Contains(col, `\`) {
+			cols[i] = utils.TrimIfNeeded(col)
+			continue
+		}
+		value, err := unescapeTSV(col)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", i, err)
+		}
+		cols[i] = utils.TrimIfNeeded(value)
+	}
+	return cols, nil
+}
+
+func unescapeTSV(s string) (string, error) {
+	if s == `\N` {
+		return "", nil
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return "", fmt.Errorf("%w: trailing backslash", ErrInvalidEscape)
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			return "", fmt.Errorf(`%w: \%c`, ErrInvalidEscape, s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// FixedWidthDecoder cuts each field of the layout out of the line by byte offset.
+// Fields past the end of a short line are empty, since trailing padding is often stripped.
+type FixedWidthDecoder struct {
+	Fields []config.FixedWidthField
+}
+
+func (d FixedWidthDecoder) Decode(line string) ([]string, error) {
+	cols := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		if f.Start >= len(line) {
+			continue
+		}
+		value := line[f.Start:min(f.Start+f.Length, len(line))]
+		if f.Trim {
+			value = strings.Trim(value, " ")
+		}
+		cols[i] = value
+	}
+	return cols, nil
+}
+
+// JSONLDecoder reads one JSON object per line and returns the value at each of its paths, in order.
+// A path is a top-level key or a dotted path into nested objects; a key that itself contains dots is matched first.
+// Missing keys and nulls become empty columns, numbers keep their written form, and arrays and objects
+// are returned as compact JSON.
+type JSONLDecoder struct {
+	paths []jsonPath
+}
+
+type jsonPath struct {
+	key      string
+	segments []string
+}
+
+// NewJSONLDecoder returns a decoder for the columns at paths.
+func NewJSONLDecoder(paths []string) *JSONLDecoder {
+	d := &JSONLDecoder{paths: make([]jsonPath, len(paths))}
+	for i, path := range paths {
+		d.paths[i] = jsonPath{key: path, segments: strings.Split(path, ".")}
+	}
+	return d
+}
+
+func (d *JSONLDecoder) Decode(line string) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	// Numbers are kept as written, so decimals are not rounded through float64
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedJSON, err)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("%w: expected an object", ErrMalformedJSON)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: unexpected data after the object", ErrMalformedJSON)
+	}
+	cols := make([]string, len(d.paths))
+	for i, path := range d.paths {
+		value, err := formatJSONValue(path.lookup(obj))
+		if err != nil {
+			return nil, fmt.Errorf("column %d (%s): %w", i, path.key, err)
+		}
+		cols[i] = value
+	}
+	return cols, nil
+}
+
+func (p jsonPath) lookup(obj map[string]any) any {
+	if value, ok := obj[p.key]; ok || len(p.segments) == 1 {
+		return value
+	}
+	var value any = obj
+	for _, segment := range p.segments {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[segment]
+	}
+	return value
+}
+
+func formatJSONValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return utils.TrimIfNeeded(v), nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// errDecoder fails every line, for validators built with a config that has no valid decoder.
+type errDecoder struct {
+	err error
+}
+
+func (d errDecoder) Decode(string) ([]string, error) {
+	return nil, d.err
+}
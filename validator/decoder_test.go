@@ -0,0 +1,234 @@
+package validator
+
+import (
+	"errors"
+	"go-file-parsing/config"
+	"slices"
+	"testing"
+)
+
+func TestDelimitedDecoder(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter string
+		line      string
+		want      []string
+	}{
+		{name: "unquoted", delimiter: "|", line: " a | b |c", want: []string{"a", "b", "c"}},
+		{name: "quoted delimiter", delimiter: ",", line: `1,"Smith, Barney & Co", 10`, want: []string{"1", "Smith, Barney & Co", "10"}},
+		{name: "doubled quote", delimiter: ",", line: `1,"the ""best"" job",2`, want: []string{"1", `the "best" job`, "2"}},
+		{name: "space before quote", delimiter: ",", line: `1, "a,b",2`, want: []string{"1", "a,b", "2"}},
+		{name: "empty quoted value", delimiter: ",", line: `1,"",2`, want: []string{"1", "", "2"}},
+		{name: "stray quote kept", delimiter: ",", line: `1,5'10" tall,2`, want: []string{"1", `5'10" tall`, "2"}},
+		{name: "unterminated quote takes the rest", delimiter: ",", line: `1,"a,b`, want: []string{"1", "a,b"}},
+		{name: "quoted tab", delimiter: "\t", line: "1\t\"a\tb\"\t\t2", want: []string{"1", "a\tb", "", "2"}},
+		{name: "multi-character delimiter is literal", delimiter: "||", line: `"a||b"||c`, want: []string{`"a`, `b"`, "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := DelimitedDecoder{Delimiter: tt.delimiter}.Decode(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(cols, tt.want) {
+				t.Errorf("got %q, want %q", cols, tt.want)
+			}
+		})
+	}
+}
+
+func TestTSVDecoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "plain", line: "1\t two \t3", want: []string{"1", "two", "3"}},
+		{name: "escapes", line: `a\tb` + "\t" + `line\nbreak` + "\t" + `back\\slash`, want: []string{"a\tb", "line\nbreak", `back\slash`}},
+		{name: "null", line: "1\t\\N\t3", want: []string{"1", "", "3"}},
+		{name: "unknown escape", line: "1\t\\x", wantErr: true},
+		{name: "trailing backslash", line: "1\tabc\\", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := TSVDecoder{}.Decode(tt.line)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEscape) {
+					t.Errorf("expected ErrInvalidEscape, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(cols, tt.want) {
+				t.Errorf("got %q, want %q", cols, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixedWidthDecoder(t *testing.T) {
+	d := FixedWidthDecoder{Fields: []config.FixedWidthField{
+		{Name: "id", Start: 0, Length: 4, Trim: true},
+		{Name: "code", Start: 4, Length: 3},
+		{Name: "name", Start: 7, Length: 10, Trim: true},
+	}}
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "full line", line: "12  AB Alice     ", want: []string{"12", "AB ", "Alice"}},
+		{name: "short line", line: "7   XYZBo", want: []string{"7", "XYZ", "Bo"}},
+		{name: "missing fields", line: "99", want: []string{"99", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := d.Decode(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(cols, tt.want) {
+				t.Errorf("got %q, want %q", cols, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRecordDecoder(t *testing.T) {
+	if _, err := NewRecordDecoder(&config.ParserConfig{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := NewRecordDecoder(&config.ParserConfig{Format: config.FormatFixedWidth}); err == nil {
+		t.Error("expected an error for a fixed-width format without fields")
+	}
+	d, err := NewRecordDecoder(&config.ParserConfig{Format: config.FormatTSV})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := d.(TSVDecoder); !ok {
+		t.Errorf("expected a TSVDecoder, got %T", d)
+	}
+}
+
+func TestValidate_FixedWidth(t *testing.T) {
+	conf := &config.ParserConfig{
+		Format: config.FormatFixedWidth,
+		FixedWidthFields: []config.FixedWidthField{
+			{Name: "id", Start: 0, Length: 3, Trim: true},
+			{Name: "amount", Start: 3, Length: 6, Trim: true},
+		},
+	}
+	cacheChan := make(chan CacheData, 1)
+	var got []string
+	v := New(conf, cacheChan, []ColValidator{
+		func(_ *RowValidatorContext, cols []string) (map[string]string, error) {
+			got = cols
+			return nil, nil
+		},
+	})
+	id, err := v.Validate("7  100.50")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "7" {
+		t.Errorf("expected id 7, got %q", id)
+	}
+	if want := []string{"7", "100.50"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	close(cacheChan)
+}
+
+func TestJSONLDecoder(t *testing.T) {
+	d := NewJSONLDecoder([]string{"id", "applicant.fico.low", "int_rate", "tags", "verified", "note", "a.b"})
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "nested paths",
+			line: `{"id":"7","applicant":{"fico":{"low":700}},"int_rate":13.560,"tags":["a","b"],"verified":true,"note":" hi "}`,
+			want: []string{"7", "700", "13.560", `["a","b"]`, "true", "hi", ""},
+		},
+		{
+			name: "missing and null values",
+			line: `{"id":8,"applicant":null,"note":null}`,
+			want: []string{"8", "", "", "", "", "", ""},
+		},
+		{
+			name: "dotted key preferred over nesting",
+			line: `{"id":"9","a.b":"flat","a":{"b":"nested"}}`,
+			want: []string{"9", "", "", "", "", "", "flat"},
+		},
+		{
+			name: "path through a scalar",
+			line: `{"id":"10","applicant":"unknown"}`,
+			want: []string{"10", "", "", "", "", "", ""},
+		},
+		{name: "not JSON", line: `id,amount`, wantErr: true},
+		{name: "truncated object", line: `{"id":"11"`, wantErr: true},
+		{name: "array", line: `["11"]`, wantErr: true},
+		{name: "null", line: `null`, wantErr: true},
+		{name: "trailing data", line: `{"id":"12"} {"id":"13"}`, wantErr: true},
+		{name: "empty line", line: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := d.Decode(tt.line)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformedJSON) {
+					t.Errorf("expected ErrMalformedJSON, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(cols, tt.want) {
+				t.Errorf("got %q, want %q", cols, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRecordDecoder_JSONL(t *testing.T) {
+	if _, err := NewRecordDecoder(&config.ParserConfig{Format: config.FormatJSONL}); err == nil {
+		t.Error("expected an error for a JSONL format without columns")
+	}
+	if _, err := NewRecordDecoder(&config.ParserConfig{Format: config.FormatJSONL, HasHeader: true, JSONColumns: []string{"id"}}); err == nil {
+		t.Error("expected an error for a JSONL format with a header")
+	}
+	// Without JSONColumns the names in Columns are used
+	d, err := NewRecordDecoder(&config.ParserConfig{
+		Format:  config.FormatJSONL,
+		Columns: []config.ColumnSchema{{Name: "id"}, {Name: "loan_amnt"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cols, err := d.Decode(`{"loan_amnt":"1000","id":"1"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"1", "1000"}; !slices.Equal(cols, want) {
+		t.Errorf("got %q, want %q", cols, want)
+	}
+}
+
+func TestValidate_MalformedJSONL(t *testing.T) {
+	cacheChan := make(chan CacheData, 1)
+	v := New(&config.ParserConfig{Format: config.FormatJSONL, JSONColumns: []string{"id"}}, cacheChan, []ColValidator{
+		func(_ *RowValidatorContext, _ []string) (map[string]string, error) {
+			return nil, nil
+		},
+	})
+	if _, err := v.Validate(`{"id": "1",`); !errors.Is(err, ErrMalformedJSON) {
+		t.Errorf("expected ErrMalformedJSON, got %v", err)
+	}
+	close(cacheChan)
+}
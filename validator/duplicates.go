@@ -0,0 +1,371 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-file-parsing/cache"
+	"go-file-parsing/config"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DuplicateKey names a column whose values must not repeat across rows. Empty values are not tracked.
+type DuplicateKey struct {
+	Name   string
+	Column int
+}
+
+// Occurrence is where a key value was first seen.
+type Occurrence struct {
+	Source string `json:"source"`
+	Row    int64  `json:"row"`
+	Id     string `json:"id"`
+}
+
+// ErrDuplicate is the error every DuplicateError wraps, so repeats can be counted together.
+var ErrDuplicate = errors.New("duplicate row")
+
+// DuplicateError reports a row that repeats a key value seen on an earlier row.
+type DuplicateError struct {
+	Key    string
+	Value  string
+	Source string
+	Row    int64
+	First  Occurrence
+}
+
+func (e *DuplicateError) Error() string {
+	if e.First.Source != e.Source {
+		return fmt.Sprintf("duplicate %s %s at row %d, first seen at row %d of %s", e.Key, e.Value, e.Row, e.First.Row, e.First.Source)
+	}
+	return fmt.Sprintf("duplicate %s %s at row %d, first seen at row %d", e.Key, e.Value, e.Row, e.First.Row)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// SeenStore records the first occurrence of each key value.
+type SeenStore interface {
+	// FirstSeen records occ for key if key is new. Otherwise it returns the earlier occurrence and true.
+	FirstSeen(ctx context.Context, key string, occ Occurrence) (Occurrence, bool, error)
+	// Forget removes key if it still records occ, so the next row with the key is recorded in its place.
+	Forget(ctx context.Context, key string, occ Occurrence) error
+}
+
+// memorySeenStore is guarded by a mutex: the validators call Forget while the reader calls FirstSeen.
+type memorySeenStore struct {
+	mu   sync.Mutex
+	seen map[string]Occurrence
+}
+
+// NewMemorySeenStore returns a SeenStore that only remembers keys for the current run.
+func NewMemorySeenStore() SeenStore {
+	return &memorySeenStore{seen: make(map[string]Occurrence)}
+}
+
+func (m *memorySeenStore) FirstSeen(_ context.Context, key string, occ Occurrence) (Occurrence, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if first, ok := m.seen[key]; ok {
+		return first, true, nil
+	}
+	m.seen[key] = occ
+	return occ, false, nil
+}
+
+func (m *memorySeenStore) Forget(_ context.Context, key string, occ Occurrence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[key] == occ {
+		delete(m.seen, key)
+	}
+	return nil
+}
+
+type cacheSeenStore struct {
+	cache cache.DistributedCache
+}
+
+// NewCacheSeenStore returns a SeenStore that keeps keys in the distributed cache under "seen:<key>",
+// so later runs over other files find repeats of rows parsed earlier.
+// Re-running the same file does not flag its own rows, because they are found at the same source and row.
+func NewCacheSeenStore(c cache.DistributedCache) SeenStore {
+	return &cacheSeenStore{cache: c}
+}
+
+// FirstSeen claims the key with SET NX, so two runs reading the same key at once cannot both record it as new.
+func (s *cacheSeenStore) FirstSeen(ctx context.Context, key string, occ Occurrence) (Occurrence, bool, error) {
+	cacheKey := "seen:" + key
+	data, err := json.Marshal(occ)
+	if err != nil {
+		return occ, false, err
+	}
+	claimed, err := s.cache.SetIfMissing(ctx, cacheKey, string(data))
+	if err != nil || claimed {
+		return occ, false, err
+	}
+	value, err := s.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return occ, false, err
+	}
+	var first Occurrence
+	if err = json.Unmarshal([]byte(value), &first); err != nil {
+		return occ, false, fmt.Errorf("reading %s: %w", cacheKey, err)
+	}
+	if first.Source == occ.Source && first.Row == occ.Row {
+		return occ, false, nil
+	}
+	return first, true, nil
+}
+
+// Forget reads the key before deleting it, so a key claimed since by another run is kept.
+func (s *cacheSeenStore) Forget(ctx context.Context, key string, occ Occurrence) error {
+	cacheKey := "seen:" + key
+	value, err := s.cache.Get(ctx, cacheKey)
+	if cache.IsMissing(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var first Occurrence
+	if err = json.Unmarshal([]byte(value), &first); err != nil {
+		return fmt.Errorf("reading %s: %w", cacheKey, err)
+	}
+	if first.Source != occ.Source || first.Row != occ.Row {
+		return nil
+	}
+	return s.cache.Delete(ctx, cacheKey)
+}
+
+// DuplicateDetector finds rows that repeat a key value of an earlier row and applies the configured policy.
+// Check is not safe for concurrent use: call it from the loop that reads the file, so "first" follows file order.
+// Every row Check finds new must then be passed to Validated, which may be called concurrently.
+type DuplicateDetector struct {
+	policy  string
+	decoder RecordDecoder
+	source  string
+	keys    []DuplicateKey
+	store   SeenStore
+	// logRows logs each repeated row at debug level
+	logRows bool
+	// rejected holds, per first row id, the repeat that drops it when Finish deletes its record
+	rejected map[string]*DuplicateError
+	// replacements holds, per first row id, the last repeat that replaces its record in Finish
+	replacements map[string]pendingRow
+
+	mu sync.Mutex
+	// unvalidated holds the keys recorded by each row of this file that has not been passed to Validated yet
+	unvalidated map[int64]claim
+	// validated is signalled whenever a row leaves unvalidated
+	validated *sync.Cond
+}
+
+type pendingRow struct {
+	raw    string
+	cols   []string
+	rowNum int64
+	first  Occurrence
+}
+
+// claim is the keys a row recorded as their first occurrence.
+type claim struct {
+	occ  Occurrence
+	keys []string
+}
+
+// cellsDecoder splits a spreadsheet row back into the cells its source joined with tabs.
+type cellsDecoder struct{}
+
+func (cellsDecoder) Decode(raw string) ([]string, error) {
+	return strings.Split(raw, "\t"), nil
+}
+
+// NewDuplicateDetector returns a detector for conf.DuplicatePolicy, or nil when detection is disabled.
+// source names the file being read and is recorded with every key.
+func NewDuplicateDetector(conf *config.ParserConfig, store SeenStore, source string, keys []DuplicateKey) *DuplicateDetector {
+	if conf.DuplicatePolicy == "" {
+		return nil
+	}
+	decoder, err := NewRecordDecoder(conf)
+	if conf.Format == config.FormatXLSX {
+		decoder = cellsDecoder{}
+	} else if err != nil {
+		decoder = errDecoder{err: err}
+	}
+	d := &DuplicateDetector{
+		policy:       conf.DuplicatePolicy,
+		decoder:      decoder,
+		source:       source,
+		keys:         keys,
+		store:        store,
+		logRows:      conf.Logging.Rows,
+		rejected:     make(map[string]*DuplicateError),
+		replacements: make(map[string]pendingRow),
+		unvalidated:  make(map[int64]claim),
+	}
+	d.validated = sync.NewCond(&d.mu)
+	return d
+}
+
+// Check returns the row's id and, if the row repeats an earlier key value, a *DuplicateError.
+// A repeated row should not be validated; Finish applies the policy to the first row's record.
+// When the first row is still being validated Check waits for its Validated call, and if it failed, the row is
+// recorded in its place instead.
+func (d *DuplicateDetector) Check(ctx context.Context, row string, rowNum int64) (string, *DuplicateError, error) {
+	cols, err := d.decoder.Decode(row)
+	if err != nil {
+		// The row cannot be decoded, so validation will reject it
+		return "", nil, nil
+	}
+	return d.CheckColumns(ctx, row, cols, rowNum)
+}
+
+// CheckColumns is Check for a row that was already split into columns. raw is kept for a keep-last replacement.
+func (d *DuplicateDetector) CheckColumns(ctx context.Context, raw string, cols []string, rowNum int64) (string, *DuplicateError, error) {
+	if len(cols) == 0 {
+		return "", nil, nil
+	}
+	id := cols[0]
+	occ := Occurrence{Source: d.source, Row: rowNum, Id: id}
+	var claimed []string
+	for _, key := range d.keys {
+		if key.Column >= len(cols) {
+			continue
+		}
+		value := cols[key.Column]
+		if value == "" {
+			continue
+		}
+		seenKey := key.Name + ":" + value
+		first, seen, err := d.firstSeen(ctx, seenKey, occ)
+		if err != nil {
+			return id, nil, err
+		}
+		if !seen {
+			claimed = append(claimed, seenKey)
+			continue
+		}
+		// A repeat is never written under its own id, so it gives up the keys it recorded before this one
+		if err = d.forget(ctx, claim{occ: occ, keys: claimed}); err != nil {
+			return id, nil, err
+		}
+		dupErr := &DuplicateError{Key: key.Name, Value: value, Source: d.source, Row: rowNum, First: first}
+		switch d.policy {
+		case config.DuplicateReject:
+			// A first row from an earlier file was accepted by that file's run, so its record is kept
+			if _, ok := d.rejected[first.Id]; !ok && first.Source == d.source {
+				d.rejected[first.Id] = dupErr
+			}
+		case config.DuplicateKeepLast:
+			// cols may be reused by the caller's reader once CheckColumns returns
+			d.replacements[first.Id] = pendingRow{raw: raw, cols: slices.Clone(cols), rowNum: rowNum, first: first}
+		}
+		if d.logRows {
+			slog.DebugContext(ctx, "Duplicate row", "row", rowNum, "id", id, "key", key.Name, "value", value,
+				"first_row", first.Row, "first_source", first.Source)
+		}
+		return id, dupErr, nil
+	}
+	if len(claimed) > 0 {
+		d.mu.Lock()
+		d.unvalidated[rowNum] = claim{occ: occ, keys: claimed}
+		d.mu.Unlock()
+	}
+	return id, nil, nil
+}
+
+// firstSeen is SeenStore.FirstSeen for a row of this file, waiting until the first row of key has been validated.
+// The store is asked again afterwards: if the first row failed, its keys were forgotten and occ is recorded instead.
+func (d *DuplicateDetector) firstSeen(ctx context.Context, key string, occ Occurrence) (Occurrence, bool, error) {
+	first, seen, err := d.store.FirstSeen(ctx, key, occ)
+	if err != nil || !seen || first.Source != d.source {
+		return first, seen, err
+	}
+	d.mu.Lock()
+	for {
+		if _, pending := d.unvalidated[first.Row]; !pending {
+			break
+		}
+		d.validated.Wait()
+	}
+	d.mu.Unlock()
+	return d.store.FirstSeen(ctx, key, occ)
+}
+
+// Validated records whether row rowNum passed validation. The keys a failed row recorded are forgotten,
+// so the next row repeating them is validated in its place rather than rejected as a repeat of a row never written.
+func (d *DuplicateDetector) Validated(ctx context.Context, rowNum int64, valid bool) error {
+	d.mu.Lock()
+	// Held until the keys are forgotten, so a waiting Check asks the store after they are gone
+	defer d.mu.Unlock()
+	c, ok := d.unvalidated[rowNum]
+	if !ok {
+		return nil
+	}
+	delete(d.unvalidated, rowNum)
+	d.validated.Broadcast()
+	if valid {
+		return nil
+	}
+	return d.forget(ctx, c)
+}
+
+func (d *DuplicateDetector) forget(ctx context.Context, c claim) error {
+	for _, key := range c.keys {
+		if err := d.store.Forget(ctx, key, c.occ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finish applies the policy once every record has been written to c.
+// Rejected first rows read from this file are deleted. Under keep-last validate is called with the last repeat,
+// and only if it passes is the first row's record deleted and the repeat's record written in its place.
+// remove is called with each first row before its record is deleted, read back from the record's raw field, so the
+// caller can take the row out of its totals. dupErr is the repeat that dropped it under reject, or nil if it was replaced.
+// cols is nil if the raw line could not be read back.
+func (d *DuplicateDetector) Finish(ctx context.Context, c cache.DistributedCache,
+	validate func(raw string, cols []string, rowNum int64) (CacheData, error),
+	remove func(first Occurrence, raw string, cols []string, dupErr *DuplicateError)) error {
+	for _, dupErr := range d.rejected {
+		if err := d.remove(ctx, c, dupErr.First, dupErr, remove); err != nil {
+			return err
+		}
+	}
+	for _, pending := range d.replacements {
+		data, err := validate(pending.raw, pending.cols, pending.rowNum)
+		if err != nil {
+			// The repeat is invalid, so the first row stays
+			continue
+		}
+		// Fields are written with HSETNX, so the old record must go before the new one is written
+		if err = d.remove(ctx, c, pending.first, nil, remove); err != nil {
+			PutMap(data.Data)
+			return err
+		}
+		WriteCacheData(ctx, c, data)
+	}
+	return nil
+}
+
+// remove passes the first row to the caller's remove and deletes its record.
+func (d *DuplicateDetector) remove(ctx context.Context, c cache.DistributedCache, first Occurrence, dupErr *DuplicateError,
+	remove func(first Occurrence, raw string, cols []string, dupErr *DuplicateError)) error {
+	raw, err := c.GetField(ctx, first.Id, "raw")
+	if err != nil && !cache.IsMissing(err) {
+		return err
+	}
+	var cols []string
+	if err == nil {
+		cols, _ = DecodeLine(d.decoder, raw)
+	}
+	remove(first, raw, cols, dupErr)
+	return c.Delete(ctx, first.Id)
+}
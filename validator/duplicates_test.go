@@ -0,0 +1,368 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"go-file-parsing/config"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// fakeCache is an in-memory DistributedCache. Missing keys return the same error as Valkey.
+type fakeCache struct {
+	values  map[string]string
+	deleted []string
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{values: make(map[string]string)}
+}
+
+func (f *fakeCache) Get(_ context.Context, key string) (string, error) {
+	if v, ok := f.values[key]; ok {
+		return v, nil
+	}
+	return "", valkey.Nil
+}
+
+func (f *fakeCache) Set(_ context.Context, key, value string) error {
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeCache) SetField(_ context.Context, key, field, value string) error {
+	f.values[key+"."+field] = value
+	return nil
+}
+
+func (f *fakeCache) GetField(ctx context.Context, key, field string) (string, error) {
+	return f.Get(ctx, key+"."+field)
+}
+
+func (f *fakeCache) SetIfMissing(_ context.Context, key, value string) (bool, error) {
+	if _, ok := f.values[key]; ok {
+		return false, nil
+	}
+	f.values[key] = value
+	return true, nil
+}
+
+// Delete removes key and, as for a hash, every field written under it.
+func (f *fakeCache) Delete(_ context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	delete(f.values, key)
+	maps.DeleteFunc(f.values, func(k, _ string) bool { return strings.HasPrefix(k, key+".") })
+	return nil
+}
+
+func (f *fakeCache) Close() {}
+
+var testDuplicateKeys = []DuplicateKey{{Name: "id", Column: 0}, {Name: "member_id", Column: 1}}
+
+func TestDuplicateDetector_Disabled(t *testing.T) {
+	d := NewDuplicateDetector(&config.ParserConfig{Delimiter: ","}, NewMemorySeenStore(), "a.csv", testDuplicateKeys)
+	if d != nil {
+		t.Errorf("expected no detector without a DuplicatePolicy")
+	}
+}
+
+func TestDuplicateDetector_Check(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateKeepFirst}
+	d := NewDuplicateDetector(conf, NewMemorySeenStore(), "a.csv", testDuplicateKeys)
+	ctx := context.Background()
+
+	rows := []struct {
+		row     string
+		wantErr string
+	}{
+		{row: "100,,a"},
+		{row: "101,,b"},
+		{row: "100,,c", wantErr: "duplicate id 100 at row 3, first seen at row 1"},
+		{row: "102,m1,d"},
+		{row: "103,m1,e", wantErr: "duplicate member_id m1 at row 5, first seen at row 4"},
+		{row: "104,,f"},
+	}
+	for i, r := range rows {
+		rowNum := int64(i + 1)
+		id, dupErr, err := d.Check(ctx, r.row, rowNum)
+		if err != nil {
+			t.Fatalf("row %d: unexpected error: %v", rowNum, err)
+		}
+		if err = d.Validated(ctx, rowNum, true); err != nil {
+			t.Fatalf("row %d: unexpected error: %v", rowNum, err)
+		}
+		if id != r.row[:3] {
+			t.Errorf("row %d: expected id %s, got %s", rowNum, r.row[:3], id)
+		}
+		if r.wantErr == "" {
+			if dupErr != nil {
+				t.Errorf("row %d: unexpected duplicate: %v", rowNum, dupErr)
+			}
+			continue
+		}
+		if dupErr == nil || dupErr.Error() != r.wantErr {
+			t.Errorf("row %d: expected '%s', got '%v'", rowNum, r.wantErr, dupErr)
+		}
+	}
+}
+
+func TestDuplicateDetector_Finish(t *testing.T) {
+	testCases := []struct {
+		name         string
+		policy       string
+		invalid      bool
+		wantDeleted  []string
+		wantRemoved  []string
+		wantReplaced []int64
+		wantValues   map[string]string
+	}{
+		{
+			name:        "reject",
+			policy:      config.DuplicateReject,
+			wantDeleted: []string{"100"},
+			wantRemoved: []string{"100,,a: duplicate id 100 at row 3, first seen at row 1"},
+			wantValues:  map[string]string{},
+		},
+		{name: "keep-first", policy: config.DuplicateKeepFirst},
+		{
+			name:         "keep-last",
+			policy:       config.DuplicateKeepLast,
+			wantDeleted:  []string{"100"},
+			wantRemoved:  []string{"100,,a"},
+			wantReplaced: []int64{4},
+			wantValues:   map[string]string{"100.row": "4"},
+		},
+		{
+			name:         "keep-last with an invalid repeat",
+			policy:       config.DuplicateKeepLast,
+			invalid:      true,
+			wantReplaced: []int64{4},
+			wantValues:   map[string]string{"100.raw": "100,,a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: tc.policy}
+			d := NewDuplicateDetector(conf, NewMemorySeenStore(), "a.csv", testDuplicateKeys)
+			ctx := context.Background()
+			for i, row := range []string{"100,,a", "101,,b", "100,,c", "100,,d"} {
+				if _, _, err := d.Check(ctx, row, int64(i+1)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := d.Validated(ctx, int64(i+1), true); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			c := newFakeCache()
+			c.values["100.raw"] = "100,,a"
+			var removed []string
+			var replaced []int64
+			err := d.Finish(ctx, c, func(_ string, cols []string, rowNum int64) (CacheData, error) {
+				replaced = append(replaced, rowNum)
+				if tc.invalid {
+					return CacheData{}, errors.New("invalid row")
+				}
+				data := getMap()
+				data["row"] = strconv.FormatInt(rowNum, 10)
+				return CacheData{Id: cols[0], Data: data}, nil
+			}, func(first Occurrence, raw string, cols []string, dupErr *DuplicateError) {
+				if first.Id != cols[0] || first.Row != 1 {
+					t.Errorf("expected first row 1 with id %s, got %+v", cols[0], first)
+				}
+				if dupErr != nil {
+					raw += ": " + dupErr.Error()
+				}
+				removed = append(removed, raw)
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(c.deleted, tc.wantDeleted) {
+				t.Errorf("expected deleted %v, got %v", tc.wantDeleted, c.deleted)
+			}
+			if !slices.Equal(removed, tc.wantRemoved) {
+				t.Errorf("expected removed rows %v, got %v", tc.wantRemoved, removed)
+			}
+			if !slices.Equal(replaced, tc.wantReplaced) {
+				t.Errorf("expected replaced rows %v, got %v", tc.wantReplaced, replaced)
+			}
+			if tc.wantValues != nil && !maps.Equal(c.values, tc.wantValues) {
+				t.Errorf("expected cache %v, got %v", tc.wantValues, c.values)
+			}
+		})
+	}
+}
+
+func TestDuplicateDetector_FinishKeepsEarlierFiles(t *testing.T) {
+	c := newFakeCache()
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateReject}
+	ctx := context.Background()
+
+	first := NewDuplicateDetector(conf, NewCacheSeenStore(c), "a.csv", testDuplicateKeys)
+	if _, _, err := first.Check(ctx, "100,,a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := NewDuplicateDetector(conf, NewCacheSeenStore(c), "b.csv", testDuplicateKeys)
+	if _, dupErr, err := second.Check(ctx, "100,,z", 7); err != nil || dupErr == nil {
+		t.Fatalf("expected a duplicate of a.csv, got %v, %v", dupErr, err)
+	}
+	if err := second.Finish(ctx, c, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.deleted) != 0 {
+		t.Errorf("expected the record from a.csv to be kept, got deleted %v", c.deleted)
+	}
+}
+
+func TestDuplicateDetector_InvalidFirstRow(t *testing.T) {
+	for _, policy := range []string{config.DuplicateReject, config.DuplicateKeepFirst, config.DuplicateKeepLast} {
+		t.Run(policy, func(t *testing.T) {
+			conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: policy}
+			d := NewDuplicateDetector(conf, NewMemorySeenStore(), "a.csv", testDuplicateKeys)
+			ctx := context.Background()
+			if _, dupErr, err := d.Check(ctx, "100,m1,a", 1); err != nil || dupErr != nil {
+				t.Fatalf("expected the first copy to be new, got %v, %v", dupErr, err)
+			}
+
+			// The second copy is checked while the first is still being validated, and waits for it
+			checked := make(chan *DuplicateError)
+			go func() {
+				_, dupErr, err := d.Check(ctx, "100,m1,b", 2)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				checked <- dupErr
+			}()
+			select {
+			case dupErr := <-checked:
+				t.Fatalf("expected the second copy to wait for the first, got %v", dupErr)
+			case <-time.After(20 * time.Millisecond):
+			}
+			if err := d.Validated(ctx, 1, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dupErr := <-checked; dupErr != nil {
+				t.Fatalf("expected the second copy to replace the invalid first, got %v", dupErr)
+			}
+			if err := d.Validated(ctx, 2, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, dupErr, err := d.Check(ctx, "100,m1,c", 3)
+			want := "duplicate id 100 at row 3, first seen at row 2"
+			if err != nil || dupErr == nil || dupErr.Error() != want {
+				t.Errorf("expected '%s', got '%v', %v", want, dupErr, err)
+			}
+
+			c := newFakeCache()
+			c.values["100.raw"] = "100,m1,b"
+			err = d.Finish(ctx, c, func(string, []string, int64) (CacheData, error) {
+				return CacheData{Id: "100", Data: getMap()}, nil
+			}, func(first Occurrence, _ string, _ []string, _ *DuplicateError) {
+				if first.Row != 2 {
+					t.Errorf("expected the valid second copy to be the first row, got row %d", first.Row)
+				}
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDuplicateDetector_RepeatForgetsItsKeys(t *testing.T) {
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateKeepFirst}
+	d := NewDuplicateDetector(conf, NewMemorySeenStore(), "a.csv", testDuplicateKeys)
+	ctx := context.Background()
+	for i, row := range []string{"100,m1,a", "101,m1,b", "101,m2,c"} {
+		_, dupErr, err := d.Check(ctx, row, int64(i+1))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = d.Validated(ctx, int64(i+1), true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Row 2 repeats member_id m1, so the id 101 it recorded first is left for row 3
+		if wantDup := i == 1; (dupErr != nil) != wantDup {
+			t.Errorf("row %d: expected duplicate %v, got %v", i+1, wantDup, dupErr)
+		}
+	}
+}
+
+func TestCacheSeenStore_AcrossFiles(t *testing.T) {
+	c := newFakeCache()
+	conf := &config.ParserConfig{Delimiter: ",", DuplicatePolicy: config.DuplicateReject}
+	ctx := context.Background()
+
+	first := NewDuplicateDetector(conf, NewCacheSeenStore(c), "a.csv", testDuplicateKeys)
+	if _, dupErr, err := first.Check(ctx, "100,,a", 1); err != nil || dupErr != nil {
+		t.Fatalf("expected first row to be new, got %v, %v", dupErr, err)
+	}
+
+	// Re-running the same file does not flag its own rows
+	rerun := NewDuplicateDetector(conf, NewCacheSeenStore(c), "a.csv", testDuplicateKeys)
+	if _, dupErr, err := rerun.Check(ctx, "100,,a", 1); err != nil || dupErr != nil {
+		t.Fatalf("expected re-run row to be new, got %v, %v", dupErr, err)
+	}
+
+	second := NewDuplicateDetector(conf, NewCacheSeenStore(c), "b.csv", testDuplicateKeys)
+	_, dupErr, err := second.Check(ctx, "100,,z", 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "duplicate id 100 at row 7, first seen at row 1 of a.csv"
+	if dupErr == nil || dupErr.Error() != want {
+		t.Errorf("expected '%s', got '%v'", want, dupErr)
+	}
+}
+
+func TestCacheSeenStore_Forget(t *testing.T) {
+	c := newFakeCache()
+	store := NewCacheSeenStore(c)
+	ctx := context.Background()
+	first := Occurrence{Source: "a.csv", Row: 1, Id: "100"}
+	if _, _, err := store.FirstSeen(ctx, "id:100", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another row does not own the key
+	if err := store.Forget(ctx, "id:100", Occurrence{Source: "b.csv", Row: 1, Id: "100"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, seen, _ := store.FirstSeen(ctx, "id:100", Occurrence{Source: "b.csv", Row: 2}); !seen {
+		t.Errorf("expected the key of a.csv to be kept")
+	}
+
+	if err := store.Forget(ctx, "id:100", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, seen, _ := store.FirstSeen(ctx, "id:100", Occurrence{Source: "b.csv", Row: 2}); seen {
+		t.Errorf("expected the forgotten key to be recorded again")
+	}
+	if err := store.Forget(ctx, "id:200", first); err != nil {
+		t.Errorf("expected a missing key to be ignored, got %v", err)
+	}
+}
+
+func TestCacheSeenStore_CacheError(t *testing.T) {
+	store := NewCacheSeenStore(&failingCache{fakeCache: newFakeCache()})
+	if _, _, err := store.FirstSeen(context.Background(), "id:100", Occurrence{}); err == nil {
+		t.Errorf("expected cache error to be returned")
+	}
+}
+
+type failingCache struct {
+	*fakeCache
+}
+
+func (f *failingCache) SetIfMissing(context.Context, string, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
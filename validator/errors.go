@@ -0,0 +1,48 @@
+package validator
+
+import "errors"
+
+// RuleError is the error of a named validation rule. Its message is the rule's error, unchanged.
+type RuleError struct {
+	Rule string
+	Err  error
+}
+
+func (e *RuleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// NamedRule returns v with its errors wrapped in a *RuleError naming rule, so they can be counted per rule.
+func NamedRule(rule string, v ColValidator) ColValidator {
+	return func(vCtx *RowValidatorContext, cols []string) (map[string]string, error) {
+		data, err := v(vCtx, cols)
+		if err != nil {
+			return data, &RuleError{Rule: rule, Err: err}
+		}
+		return data, nil
+	}
+}
+
+// ErrorCode returns a short label for the cause of a row error: the rule that rejected the row,
+// "duplicate" for a repeated row, the kind of input error for a row that could not be read, or "other".
+func ErrorCode(err error) string {
+	var ruleErr *RuleError
+	var dupErr *DuplicateError
+	switch {
+	case errors.As(err, &ruleErr):
+		return ruleErr.Rule
+	case errors.As(err, &dupErr):
+		return "duplicate"
+	case errors.Is(err, ErrInvalidEncoding):
+		return "invalid_encoding"
+	case errors.Is(err, ErrMalformedJSON):
+		return "malformed_json"
+	case errors.Is(err, ErrInvalidEscape):
+		return "invalid_escape"
+	}
+	return "other"
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNamedRule(t *testing.T) {
+	errBad := errors.New("bad column")
+	v := NamedRule("loan_amount", func(_ *RowValidatorContext, _ []string) (map[string]string, error) {
+		return nil, errBad
+	})
+	_, err := v(nil, nil)
+	if err == nil || err.Error() != "bad column" {
+		t.Fatalf("expected the rule's message unchanged, got %v", err)
+	}
+	if !errors.Is(err, errBad) {
+		t.Errorf("expected the rule's error to be wrapped")
+	}
+
+	ok := NamedRule("term", func(_ *RowValidatorContext, _ []string) (map[string]string, error) {
+		return nil, nil
+	})
+	if _, err = ok(nil, nil); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want string
+	}{
+		{err: &RuleError{Rule: "loan_amount", Err: errors.New("loan amount is not a number")}, want: "loan_amount"},
+		{err: &DuplicateError{Key: "id", Value: "1"}, want: "duplicate"},
+		{err: fmt.Errorf("%w at byte 3 of the line", ErrInvalidEncoding), want: "invalid_encoding"},
+		{err: fmt.Errorf("%w: expected an object", ErrMalformedJSON), want: "malformed_json"},
+		{err: fmt.Errorf("column 2: %w", ErrInvalidEscape), want: "invalid_escape"},
+		{err: errors.New("row has no columns"), want: "other"},
+	}
+	for _, tc := range testCases {
+		if got := ErrorCode(tc.err); got != tc.want {
+			t.Errorf("%v: expected %s, got %s", tc.err, tc.want, got)
+		}
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"go-file-parsing/config"
 	"golang.org/x/sync/errgroup"
-	"strings"
+	"log/slog"
 	"sync"
 )
 
 type CsvRowValidator struct {
 	config        *config.ParserConfig
+	decoder       RecordDecoder
+	check         ColValidator
 	colValidators []ColValidator
+	derivations   []Derivation
 	cacheChan     chan CacheData
 	closed        bool
 }
@@ -21,17 +24,44 @@ func (c *CsvRowValidator) Validate(row string) (string, error) {
 	}
 
 	//Split the columns, then set the first value to the raw data string (for debug purposes)
-	cols := PreprocessColumns(strings.Split(row, c.config.Delimiter))
+	cols, err := c.decode(row)
+	if err != nil {
+		return "", err
+	}
+	return c.ValidateColumns(row, cols)
+}
+
+// ValidateColumns validates a row that was already split into columns, such as a spreadsheet row.
+// raw is cached as the row's raw data.
+func (c *CsvRowValidator) ValidateColumns(raw string, cols []string) (string, error) {
+	if c.closed {
+		return "", fmt.Errorf("validator is closed")
+	}
+	if len(cols) == 0 {
+		return "", fmt.Errorf("row has no columns")
+	}
 	id := cols[0]
 
 	vCtx := RowValidatorContext{
 		Config: c.config,
 		GetMap: getMap,
 	}
+	if c.check != nil {
+		// The check's fields are not cached; it only decides whether the validators run
+		data, err := c.check(&vCtx, cols)
+		if data != nil {
+			PutMap(data)
+		}
+		if err != nil {
+			c.logRejected(id, err)
+			return id, err
+		}
+	}
+
 	mu := sync.Mutex{}
 	m := vCtx.GetMap()
 	m["id"] = id
-	m["raw"] = row
+	m["raw"] = raw
 
 	var g errgroup.Group
 
@@ -56,18 +86,41 @@ func (c *CsvRowValidator) Validate(row string) (string, error) {
 
 	err := g.Wait()
 	if err != nil {
+		c.logRejected(id, err)
 		PutMap(m)
 		return id, err
 	}
 
+	for _, derive := range c.derivations {
+		derive(&vCtx, cols, m)
+	}
+
+	if c.config.Logging.Rows {
+		slog.Debug("Row valid", "id", id, "fields", len(m))
+	}
 	c.cacheChan <- CacheData{
 		Id:   id,
 		Data: m,
+		Size: len(raw),
 	}
 
 	return id, err // returns the first error (if any), cancels other goroutines
 }
 
+func (c *CsvRowValidator) logRejected(id string, err error) {
+	if c.config.Logging.Rows {
+		slog.Debug("Row rejected", "id", id, "code", ErrorCode(err), "err", err)
+	}
+}
+
+// decode splits row with the validator's decoder. Validators built without New split on the configured Delimiter.
+func (c *CsvRowValidator) decode(row string) ([]string, error) {
+	if c.decoder == nil {
+		return DecodeLine(DelimitedDecoder{Delimiter: c.config.Delimiter}, row)
+	}
+	return DecodeLine(c.decoder, row)
+}
+
 // Close closes the validator and releases resources.
 // It should be called when the validator is no longer needed.
 func (c *CsvRowValidator) Close() {
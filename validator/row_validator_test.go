@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"go-file-parsing/config"
 	"strings"
@@ -350,3 +351,116 @@ func isNumeric(s string) bool {
 	}
 	return len(s) > 0
 }
+
+func TestValidate_Derivations(t *testing.T) {
+	validator := func(vCtx *RowValidatorContext, cols []string) (map[string]string, error) {
+		if cols[1] == "bad" {
+			return nil, fmt.Errorf("bad column")
+		}
+		m := vCtx.GetMap()
+		m["value"] = cols[1]
+		return m, nil
+	}
+	derive := func(_ *RowValidatorContext, cols []string, record map[string]string) {
+		record["derived"] = record["value"] + "-" + cols[2]
+	}
+	cacheChan := make(chan CacheData, 20)
+	defer close(cacheChan)
+	v := New(&config.ParserConfig{Delimiter: ","}, cacheChan, []ColValidator{validator}, derive)
+
+	if _, err := v.Validate("id1,good,x"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	data := <-cacheChan
+	if data.Data["derived"] != "good-x" {
+		t.Errorf("expected derived field 'good-x', got %q", data.Data["derived"])
+	}
+
+	if _, err := v.Validate("id2,bad,x"); err == nil {
+		t.Fatalf("expected error for invalid row")
+	}
+	select {
+	case data := <-cacheChan:
+		t.Errorf("expected no cached record for an invalid row, got %v", data)
+	default:
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	var got []string
+	validator := func(_ *RowValidatorContext, cols []string) (map[string]string, error) {
+		got = cols
+		return nil, nil
+	}
+	cacheChan := make(chan CacheData, 1)
+	defer close(cacheChan)
+	// Spreadsheet rows have no line to decode
+	v := New(&config.ParserConfig{Format: config.FormatXLSX}, cacheChan, []ColValidator{validator})
+
+	id, err := v.ValidateColumns("id1\ta,b", []string{"id1", "a,b"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if id != "id1" || len(got) != 2 || got[1] != "a,b" {
+		t.Errorf("expected the columns as given, got id %q and %q", id, got)
+	}
+	data := <-cacheChan
+	if data.Data["raw"] != "id1\ta,b" {
+		t.Errorf("expected raw to be cached, got %q", data.Data["raw"])
+	}
+
+	if _, err = v.Validate("id1,a"); !errors.Is(err, ErrNotLineBased) {
+		t.Errorf("expected ErrNotLineBased from Validate, got %v", err)
+	}
+	if _, err = v.ValidateColumns("", nil); err == nil {
+		t.Errorf("expected an error for a row without columns")
+	}
+}
+
+func TestValidate_InvalidEncoding(t *testing.T) {
+	called := false
+	validator := func(_ *RowValidatorContext, _ []string) (map[string]string, error) {
+		called = true
+		return nil, nil
+	}
+	cacheChan := make(chan CacheData, 1)
+	defer close(cacheChan)
+	v := New(&config.ParserConfig{Delimiter: ","}, cacheChan, []ColValidator{validator})
+
+	_, err := v.Validate("1,Caf\xe9 owner,x")
+	if !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("expected ErrInvalidEncoding, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "byte 5") {
+		t.Errorf("expected the offset of the invalid byte, got %v", err)
+	}
+	if called {
+		t.Errorf("expected validators not to run on an undecodable row")
+	}
+}
+
+func TestValidate_CheckRunsFirst(t *testing.T) {
+	errShort := errors.New("row is too short")
+	check := func(_ *RowValidatorContext, cols []string) (map[string]string, error) {
+		if len(cols) < 3 {
+			return nil, errShort
+		}
+		return nil, nil
+	}
+	third := func(_ *RowValidatorContext, cols []string) (map[string]string, error) {
+		return map[string]string{"third": cols[2]}, nil
+	}
+	cacheChan := make(chan CacheData, 1)
+	v := NewChecked(&config.ParserConfig{Delimiter: ","}, cacheChan, check, []ColValidator{third})
+
+	// The validator would index past the end of the row if it ran
+	if _, err := v.Validate("1,a"); !errors.Is(err, errShort) {
+		t.Errorf("expected the check's error, got %v", err)
+	}
+	if _, err := v.Validate("1,a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data := <-cacheChan; data.Data["third"] != "b" {
+		t.Errorf("expected the validator to run after the check passed, got %v", data.Data)
+	}
+}
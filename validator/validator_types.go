@@ -1,14 +1,18 @@
 package validator
 
 import (
+	"cmp"
 	"context"
 	"go-file-parsing/cache"
 	"go-file-parsing/config"
+	"log/slog"
 	"sync"
 )
 
 type RowError struct {
-	Row   int64
+	Row int64
+	// Line is the 1-based line of the row in the input file, counting the header.
+	Line  int64
 	Id    string
 	Error error
 }
@@ -16,9 +20,16 @@ type RowError struct {
 type CacheData struct {
 	Id   string
 	Data map[string]string
+	// Size is the length of the row's raw data, used to account for the memory of rows in flight.
+	Size int
 }
 type ColValidator func(*RowValidatorContext, []string) (map[string]string, error)
 
+// Derivation computes extra fields for a row that passed every ColValidator.
+// It reads the row's columns and the fields the validators cached in record, and adds its own fields to record.
+// Derivations run one at a time in registration order, so later ones can use fields added by earlier ones.
+type Derivation func(vCtx *RowValidatorContext, cols []string, record map[string]string)
+
 type RowValidatorContext struct {
 	Config *config.ParserConfig
 	GetMap func() map[string]string
@@ -26,49 +37,63 @@ type RowValidatorContext struct {
 
 type RowValidator interface {
 	Validate(row string) (string, error)
+	ValidateColumns(raw string, cols []string) (string, error)
+}
+
+func New(conf *config.ParserConfig, cacheChan chan CacheData, colValidators []ColValidator, derivations ...Derivation) CsvRowValidator {
+	return NewChecked(conf, cacheChan, nil, colValidators, derivations...)
 }
 
-func New(conf *config.ParserConfig, cacheChan chan CacheData, colValidators []ColValidator) CsvRowValidator {
+// NewChecked is New with check run on each row before the column validators, which only run when it passes.
+// Use it for checks the column validators rely on, such as the number of columns they index into.
+func NewChecked(conf *config.ParserConfig, cacheChan chan CacheData, check ColValidator, colValidators []ColValidator, derivations ...Derivation) CsvRowValidator {
+	decoder, err := NewRecordDecoder(conf)
+	if err != nil {
+		decoder = errDecoder{err: err}
+	}
 	return CsvRowValidator{
 		config:        conf,
+		decoder:       decoder,
 		cacheChan:     cacheChan,
+		check:         check,
 		colValidators: colValidators,
+		derivations:   derivations,
 		closed:        false,
 	}
 }
 
-func NewCacheChannel(cache cache.DistributedCache, wg *sync.WaitGroup, cachePoolSize int) chan CacheData {
+// NewCacheChannel returns a channel whose items are written to cache by workers goroutines.
+// The channel holds up to workers items, so senders block when the writers fall behind.
+// wg is done once the channel has been closed and every item written.
+func NewCacheChannel(cache cache.DistributedCache, wg *sync.WaitGroup, workers int) chan CacheData {
 	ctx := context.Background()
-	cacheChan := make(chan CacheData, cachePoolSize)
-	cachePool := make(chan func(data CacheData), cachePoolSize)
-	for i := 0; i < cachePoolSize; i++ {
-		cachePool <- func(cacheItem CacheData) {
-			for key, value := range cacheItem.Data {
-				_ = cache.SetField(ctx, cacheItem.Id, key, value)
+	cacheChan := make(chan CacheData, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cacheItem := range cacheChan {
+				WriteCacheData(ctx, cache, cacheItem)
 			}
-			// Return the map to the pool after use
-			PutMap(cacheItem.Data)
-		}
+		}()
 	}
-	wg.Add(1)
-	//Spin off a new goroutine that will write to the cache as it processes from the channel
-	go func() {
-		defer wg.Done()
-		for cacheItem := range cacheChan {
-			worker := <-cachePool
-			go func(ci CacheData) {
-				defer func() {
-					// Return the worker to the pool even if there's a panic
-					cachePool <- worker
+	return cacheChan
+}
 
-					// If there's a panic, recover and ensure the map is returned to the pool
-					if r := recover(); r != nil {
-						PutMap(ci.Data)
-					}
-				}()
-				worker(ci)
-			}(cacheItem)
+// WriteCacheData writes each field of item to the cache hash named by its id and returns the map to the pool.
+// A record with fields that could not be written is logged once as a warning and the other fields are still written.
+func WriteCacheData(ctx context.Context, cache cache.DistributedCache, item CacheData) {
+	// Return the map to the pool even if a write panics
+	defer PutMap(item.Data)
+	failed := 0
+	var firstErr error
+	for key, value := range item.Data {
+		if err := cache.SetField(ctx, item.Id, key, value); err != nil {
+			failed++
+			firstErr = cmp.Or(firstErr, err)
 		}
-	}()
-	return cacheChan
+	}
+	if failed > 0 {
+		slog.WarnContext(ctx, "Error writing record to cache", "id", item.Id, "failed_fields", failed, "err", firstErr)
+	}
 }
@@ -0,0 +1,184 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"go-file-parsing/utils"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTimeLayout is used for date cells with a time of day when Options.DateLayout is empty.
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// significantDigits is the precision Excel keeps for numbers. Rounding to it removes the binary noise
+// of values such as 0.1356 stored as 0.13560000000000003.
+const significantDigits = 15
+
+// Serial day numbers count from these dates. The 1900 system counts a 29 February 1900 that did not exist,
+// so its epoch is the 30th of December for every serial after that day.
+var (
+	epoch1900 = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	epoch1904 = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type numberKind int
+
+const (
+	kindNumber numberKind = iota
+	kindPercent
+	kindDate
+)
+
+// styles maps each cell style to how its numeric values are written.
+type styles struct {
+	kinds    []numberKind
+	date1904 bool
+}
+
+func readStyles(f *zip.File) (styles, error) {
+	var doc struct {
+		NumFmts struct {
+			NumFmt []struct {
+				Id   int    `xml:"numFmtId,attr"`
+				Code string `xml:"formatCode,attr"`
+			} `xml:"numFmt"`
+		} `xml:"numFmts"`
+		CellXfs struct {
+			Xf []struct {
+				NumFmtId int `xml:"numFmtId,attr"`
+			} `xml:"xf"`
+		} `xml:"cellXfs"`
+	}
+	if err := decodeFile(f, &doc); err != nil {
+		return styles{}, err
+	}
+	custom := make(map[int]string, len(doc.NumFmts.NumFmt))
+	for _, nf := range doc.NumFmts.NumFmt {
+		custom[nf.Id] = nf.Code
+	}
+	s := styles{kinds: make([]numberKind, len(doc.CellXfs.Xf))}
+	for i, xf := range doc.CellXfs.Xf {
+		if code, ok := custom[xf.NumFmtId]; ok {
+			s.kinds[i] = codeKind(code)
+		} else {
+			s.kinds[i] = builtinKind(xf.NumFmtId)
+		}
+	}
+	return s, nil
+}
+
+// builtinKind classifies the number formats that are predefined by id rather than written in the workbook.
+func builtinKind(id int) numberKind {
+	switch {
+	case id == 9 || id == 10:
+		return kindPercent
+	case id >= 14 && id <= 22, id >= 27 && id <= 36, id >= 45 && id <= 47, id >= 50 && id <= 58:
+		return kindDate
+	}
+	return kindNumber
+}
+
+// codeKind classifies a custom format code by its first section, ignoring quoted text, escaped characters and
+// bracketed colors or conditions.
+func codeKind(code string) numberKind {
+	section, _, _ := strings.Cut(code, ";")
+	var b strings.Builder
+	for i := 0; i < len(section); i++ {
+		switch c := section[i]; c {
+		case '"':
+			end := strings.IndexByte(section[i+1:], '"')
+			if end < 0 {
+				i = len(section)
+			} else {
+				i += end + 1
+			}
+		case '\\', '_', '*':
+			i++
+		case '[':
+			end := strings.IndexByte(section[i:], ']')
+			if end < 0 {
+				i = len(section)
+				continue
+			}
+			// Elapsed time such as [h]:mm is still a date format; colors, locales and conditions are not
+			if inner := strings.ToLower(section[i+1 : i+end]); inner != "" && strings.Trim(inner, "hms") == "" {
+				b.WriteString(inner)
+			}
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	plain := strings.ToLower(b.String())
+	switch {
+	case strings.ContainsAny(plain, "ymdhs") && !strings.Contains(plain, "general"):
+		return kindDate
+	case strings.Contains(plain, "%"):
+		return kindPercent
+	}
+	return kindNumber
+}
+
+// format writes a numeric cell value according to its style: dates in layout, percentages with a "%" suffix
+// and other numbers in plain decimal notation.
+func (s styles) format(value string, style int, layout string) (string, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %q", value)
+	}
+	kind := kindNumber
+	if style >= 0 && style < len(s.kinds) {
+		kind = s.kinds[style]
+	}
+	switch kind {
+	case kindDate:
+		return s.formatDate(f, layout), nil
+	case kindPercent:
+		return formatNumber(f*100) + "%", nil
+	}
+	return formatNumber(f), nil
+}
+
+func formatNumber(f float64) string {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'e', significantDigits-1, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func (s styles) formatDate(serial float64, layout string) string {
+	days := math.Floor(serial)
+	epoch := epoch1900
+	switch {
+	case s.date1904:
+		epoch = epoch1904
+	case days < 60:
+		// Before the phantom 29 February 1900
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	// Rounded to the second, as Excel displays times
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	return formatTime(t, seconds != 0, layout)
+}
+
+// formatISODate rewrites a cell of type "d", which holds an ISO 8601 date or date-time, in layout.
+func formatISODate(value, layout string) string {
+	for _, l := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", utils.ISODateLayout} {
+		if t, err := time.Parse(l, value); err == nil {
+			return formatTime(t, l != utils.ISODateLayout, layout)
+		}
+	}
+	return value
+}
+
+func formatTime(t time.Time, hasTime bool, layout string) string {
+	switch {
+	case layout != "":
+		return t.Format(layout)
+	case hasTime:
+		return t.Format(dateTimeLayout)
+	}
+	return t.Format(utils.ISODateLayout)
+}
@@ -0,0 +1,431 @@
+// Package xlsx streams the rows of one sheet of an Excel (.xlsx) workbook as text columns.
+//
+// Only the shared strings and the styles are loaded up front; sheet rows are decoded one at a time,
+// so memory does not grow with the number of rows.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotWorkbook   = errors.New("not an xlsx workbook")
+	ErrSheetNotFound = errors.New("sheet not found")
+)
+
+// Options select the sheet and header row, and how date cells are written.
+type Options struct {
+	// Sheet is the name of the sheet to read. When empty, SheetIndex picks it, 0 being the first sheet.
+	Sheet      string
+	SheetIndex int
+	// HeaderRow is the 1-based row number of the header. Rows above it are skipped. Zero means there is no header.
+	HeaderRow int
+	// DateLayout is the Go time layout date cells are written in. Empty uses "2006-01-02",
+	// or "2006-01-02 15:04:05" for cells with a time of day.
+	DateLayout string
+}
+
+// Reader reads the rows of one sheet. It is not safe for concurrent use.
+type Reader struct {
+	zr      *zip.ReadCloser
+	sheet   io.ReadCloser
+	dec     *xml.Decoder
+	name    string
+	strings []string
+	styles  styles
+	opts    Options
+	header  []string
+	row     int64
+	cols    []string
+	// pending is set when r.cols holds a row read ahead by Open that Read has yet to return
+	pending bool
+}
+
+// Open opens the workbook at filename and positions the reader after the header row.
+func Open(filename string, opts Options) (*Reader, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotWorkbook, err)
+	}
+	r := &Reader{zr: zr, opts: opts}
+	if err = r.open(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reader) open() error {
+	files := make(map[string]*zip.File, len(r.zr.File))
+	for _, f := range r.zr.File {
+		files[f.Name] = f
+	}
+	wb, err := readWorkbook(files)
+	if err != nil {
+		return err
+	}
+	sheetPath, err := wb.sheetPath(r.opts)
+	if err != nil {
+		return err
+	}
+	r.name = wb.sheetName
+	if f, ok := files[wb.sharedStringsPath]; ok {
+		if r.strings, err = readSharedStrings(f); err != nil {
+			return err
+		}
+	}
+	if f, ok := files[wb.stylesPath]; ok {
+		if r.styles, err = readStyles(f); err != nil {
+			return err
+		}
+	}
+	r.styles.date1904 = wb.date1904
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", ErrNotWorkbook, sheetPath)
+	}
+	if r.sheet, err = f.Open(); err != nil {
+		return err
+	}
+	r.dec = xml.NewDecoder(r.sheet)
+	if r.opts.HeaderRow == 0 {
+		return nil
+	}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			// A sheet without data rows still has its header, if any
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r.row >= int64(r.opts.HeaderRow) {
+			if r.row == int64(r.opts.HeaderRow) {
+				r.header = slices.Clone(row)
+				return nil
+			}
+			// The header row is empty and the first data row follows it; keep it for the next Read
+			r.pending = true
+			return nil
+		}
+	}
+}
+
+// SheetName returns the name of the sheet being read.
+func (r *Reader) SheetName() string {
+	return r.name
+}
+
+// Header returns the header row, or nil when Options.HeaderRow is zero or the header row is empty.
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Row returns the 1-based row number of the row last returned by Read.
+func (r *Reader) Row() int64 {
+	return r.row
+}
+
+// Read returns the next row that has at least one non-empty cell, or io.EOF after the last one.
+// Missing cells are empty, and the row is as long as its last non-empty cell. The slice is reused by the next call.
+func (r *Reader) Read() ([]string, error) {
+	if r.pending {
+		r.pending = false
+		return r.cols, nil
+	}
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		if num, ok := attr(start, "r"); ok {
+			if r.row, err = strconv.ParseInt(num, 10, 64); err != nil {
+				return nil, fmt.Errorf("row %q: %w", num, err)
+			}
+		} else {
+			r.row++
+		}
+		if err = r.readRow(); err != nil {
+			return nil, fmt.Errorf("row %d: %w", r.row, err)
+		}
+		if len(r.cols) > 0 {
+			return r.cols, nil
+		}
+	}
+}
+
+// readRow decodes the cells of the current row into r.cols, up to its end element.
+func (r *Reader) readRow() error {
+	r.cols = r.cols[:0]
+	next := 0
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				// Drop trailing cells that are present only for their formatting
+				for len(r.cols) > 0 && r.cols[len(r.cols)-1] == "" {
+					r.cols = r.cols[:len(r.cols)-1]
+				}
+				return nil
+			}
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				if err = r.dec.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			column := next
+			if ref, ok := attr(t, "r"); ok {
+				if column, err = columnIndex(ref); err != nil {
+					return err
+				}
+			}
+			value, err := r.readCell(t)
+			if err != nil {
+				return fmt.Errorf("cell %s: %w", cellRef(column, r.row), err)
+			}
+			for len(r.cols) <= column {
+				r.cols = append(r.cols, "")
+			}
+			r.cols[column] = value
+			next = column + 1
+		}
+	}
+}
+
+// cell is the content of a <c> element.
+type cell struct {
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+func (r *Reader) readCell(start xml.StartElement) (string, error) {
+	var c cell
+	if err := r.dec.DecodeElement(&c, &start); err != nil {
+		return "", err
+	}
+	kind, _ := attr(start, "t")
+	switch kind {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(r.strings) {
+			return "", fmt.Errorf("invalid shared string index %q", c.Value)
+		}
+		return strings.TrimSpace(r.strings[i]), nil
+	case "inlineStr":
+		text := c.Inline.Text
+		for _, run := range c.Inline.Runs {
+			text += run.Text
+		}
+		return strings.TrimSpace(text), nil
+	case "str", "e":
+		return strings.TrimSpace(c.Value), nil
+	case "b":
+		return strconv.FormatBool(c.Value == "1"), nil
+	case "d":
+		return formatISODate(c.Value, r.opts.DateLayout), nil
+	}
+	if c.Value == "" {
+		return "", nil
+	}
+	style := 0
+	if s, ok := attr(start, "s"); ok {
+		style, _ = strconv.Atoi(s)
+	}
+	return r.styles.format(c.Value, style, r.opts.DateLayout)
+}
+
+// Close releases the workbook.
+func (r *Reader) Close() error {
+	if r.sheet != nil {
+		r.sheet.Close()
+	}
+	return r.zr.Close()
+}
+
+func attr(e xml.StartElement, name string) (string, bool) {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// columnIndex returns the 0-based column of a cell reference such as "C12".
+func columnIndex(ref string) (int, error) {
+	column := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		column = column*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return column - 1, nil
+}
+
+// cellRef is the inverse of columnIndex, for error messages.
+func cellRef(column int, row int64) string {
+	var name []byte
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = append([]byte{byte('A' + (column-1)%26)}, name...)
+	}
+	return string(name) + strconv.FormatInt(row, 10)
+}
+
+type sheetEntry struct {
+	Name string `xml:"name,attr"`
+	// Id is the relationship naming the sheet's part
+	Id string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+type workbook struct {
+	sheets            []sheetEntry
+	targets           map[string]string
+	sheetName         string
+	sharedStringsPath string
+	stylesPath        string
+	date1904          bool
+}
+
+func readWorkbook(files map[string]*zip.File) (*workbook, error) {
+	wbFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return nil, fmt.Errorf("%w: xl/workbook.xml is missing", ErrNotWorkbook)
+	}
+	var doc struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets struct {
+			Sheet []sheetEntry `xml:"sheet"`
+		} `xml:"sheets"`
+	}
+	if err := decodeFile(wbFile, &doc); err != nil {
+		return nil, err
+	}
+	wb := &workbook{
+		targets:           make(map[string]string),
+		sharedStringsPath: "xl/sharedStrings.xml",
+		stylesPath:        "xl/styles.xml",
+		date1904:          doc.Properties.Date1904 == "1" || doc.Properties.Date1904 == "true",
+	}
+	wb.sheets = doc.Sheets.Sheet
+
+	if relsFile, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		var rels struct {
+			Relationship []struct {
+				Id     string `xml:"Id,attr"`
+				Type   string `xml:"Type,attr"`
+				Target string `xml:"Target,attr"`
+			}
+		}
+		if err := decodeFile(relsFile, &rels); err != nil {
+			return nil, err
+		}
+		for _, rel := range rels.Relationship {
+			target := rel.Target
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join("xl", target)
+			}
+			wb.targets[rel.Id] = target
+			switch {
+			case strings.HasSuffix(rel.Type, "/sharedStrings"):
+				wb.sharedStringsPath = target
+			case strings.HasSuffix(rel.Type, "/styles"):
+				wb.stylesPath = target
+			}
+		}
+	}
+	return wb, nil
+}
+
+// sheetPath returns the part holding the sheet chosen by opts.
+func (wb *workbook) sheetPath(opts Options) (string, error) {
+	index := -1
+	if opts.Sheet != "" {
+		for i, s := range wb.sheets {
+			if s.Name == opts.Sheet {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", fmt.Errorf("%w: no sheet named %q", ErrSheetNotFound, opts.Sheet)
+		}
+	} else {
+		if opts.SheetIndex < 0 || opts.SheetIndex >= len(wb.sheets) {
+			return "", fmt.Errorf("%w: index %d, the workbook has %d sheets", ErrSheetNotFound, opts.SheetIndex, len(wb.sheets))
+		}
+		index = opts.SheetIndex
+	}
+	sheet := wb.sheets[index]
+	wb.sheetName = sheet.Name
+	if target, ok := wb.targets[sheet.Id]; ok {
+		return target, nil
+	}
+	// Workbooks without relationships name their sheets by position
+	return fmt.Sprintf("xl/worksheets/sheet%d.xml", index+1), nil
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var doc struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodeFile(f, &doc); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(doc.Items))
+	for i, item := range doc.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		values[i] = text
+	}
+	return values, nil
+}
+
+func decodeFile(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err = xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrNotWorkbook, f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<workbookPr/>
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="Loans" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`
+
+const testRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/data.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const testSharedStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>id</t></si><si><t>loan_amnt</t></si><si><t>int_rate</t></si><si><t>issue_d</t></si><si><t>grade</t></si>
+<si><r><t>B</t></r><r><t>2</t></r><rPh><t>ignored</t></rPh></si>
+</sst>`
+
+// Styles: 0 general, 1 percent (builtin 10), 2 date (builtin 14), 3 custom date, 4 custom number
+const testStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts><numFmt numFmtId="164" formatCode="[$-409]mmm\-yy;@"/><numFmt numFmtId="165" formatCode="&quot;day&quot; #,##0.00"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="10"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs>
+</styleSheet>`
+
+const testSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>Loan tape</t></is></c></row>
+<row r="3"><c r="A3" t="s"><v>0</v></c><c r="B3" t="s"><v>1</v></c><c r="C3" t="s"><v>2</v></c><c r="D3" t="s"><v>3</v></c><c r="E3" t="s"><v>4</v></c></row>
+<row r="4"><c r="A4"><v>1001</v></c><c r="B4"><v>10000.5</v></c><c r="C4" s="1"><v>0.13560000000000003</v></c><c r="D4" s="2"><v>43435</v></c><c r="E4" t="s"><v>5</v></c></row>
+<row r="5" spans="1:5"><c r="A5" s="2"/><c r="B5" s="2"/></row>
+<row r="6"><c r="A6" t="str"><f>A4+1</f><v>1002</v></c><c r="C6" s="3"><v>43435.5</v></c><c r="E6" t="b"><v>1</v></c></row>
+<row><c t="inlineStr"><is><t> 1003 </t></is></c><c s="4"><v>1.5E-3</v></c></row>
+</sheetData>
+</worksheet>`
+
+func writeWorkbook(t *testing.T, parts map[string]string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "test.xlsx")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = io.WriteString(w, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func testParts() map[string]string {
+	return map[string]string{
+		"xl/workbook.xml":            testWorkbook,
+		"xl/_rels/workbook.xml.rels": testRels,
+		"xl/sharedStrings.xml":       testSharedStrings,
+		"xl/styles.xml":              testStyles,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c t="inlineStr"><is><t>notes</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/data.xml":     testSheet,
+	}
+}
+
+func readAll(t *testing.T, r *Reader) ([][]string, []int64) {
+	t.Helper()
+	var rows [][]string
+	var nums []int64
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nums
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows = append(rows, slices.Clone(row))
+		nums = append(nums, r.Row())
+	}
+}
+
+func TestReader(t *testing.T) {
+	filename := writeWorkbook(t, testParts())
+	r, err := Open(filename, Options{Sheet: "Loans", HeaderRow: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	if want := []string{"id", "loan_amnt", "int_rate", "issue_d", "grade"}; !slices.Equal(r.Header(), want) {
+		t.Errorf("header: got %q, want %q", r.Header(), want)
+	}
+	rows, nums := readAll(t, r)
+	want := [][]string{
+		{"1001", "10000.5", "13.56%", "2018-12-01", "B2"},
+		{"1002", "", "2018-12-01 12:00:00", "", "true"},
+		{"1003", "0.0015"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows %q, want %d", len(rows), rows, len(want))
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("row %d: got %q, want %q", i, rows[i], want[i])
+		}
+	}
+	// The formatted but empty row 5 is skipped, and a row without a number follows the previous one
+	if wantNums := []int64{4, 6, 7}; !slices.Equal(nums, wantNums) {
+		t.Errorf("row numbers: got %v, want %v", nums, wantNums)
+	}
+}
+
+func TestReader_SheetSelection(t *testing.T) {
+	filename := writeWorkbook(t, testParts())
+	r, err := Open(filename, Options{SheetIndex: 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	if r.SheetName() != "Notes" {
+		t.Errorf("expected sheet Notes, got %q", r.SheetName())
+	}
+	if r.Header() != nil {
+		t.Errorf("expected no header, got %q", r.Header())
+	}
+	rows, _ := readAll(t, r)
+	if len(rows) != 1 || rows[0][0] != "notes" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+
+	for _, opts := range []Options{{Sheet: "Missing"}, {SheetIndex: 2}} {
+		if _, err = Open(filename, opts); !errors.Is(err, ErrSheetNotFound) {
+			t.Errorf("%+v: expected ErrSheetNotFound, got %v", opts, err)
+		}
+	}
+}
+
+func TestReader_DateLayout(t *testing.T) {
+	filename := writeWorkbook(t, testParts())
+	r, err := Open(filename, Options{Sheet: "Loans", HeaderRow: 3, DateLayout: "Jan-2006"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	rows, _ := readAll(t, r)
+	if rows[0][3] != "Dec-2018" || rows[1][2] != "Dec-2018" {
+		t.Errorf("expected dates in Jan-2006 layout, got %q and %q", rows[0][3], rows[1][2])
+	}
+}
+
+func TestOpen_NotWorkbook(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "loans.xlsx")
+	if err := os.WriteFile(filename, []byte("id,loan_amnt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(filename, Options{}); !errors.Is(err, ErrNotWorkbook) {
+		t.Errorf("expected ErrNotWorkbook, got %v", err)
+	}
+	filename = writeWorkbook(t, map[string]string{"docProps/app.xml": "<Properties/>"})
+	if _, err := Open(filename, Options{}); !errors.Is(err, ErrNotWorkbook) {
+		t.Errorf("expected ErrNotWorkbook for a zip without a workbook, got %v", err)
+	}
+}
+
+func TestCodeKind(t *testing.T) {
+	tests := []struct {
+		code string
+		want numberKind
+	}{
+		{"General", kindNumber},
+		{"#,##0.00", kindNumber},
+		{"0.0%", kindPercent},
+		{"yyyy-mm-dd", kindDate},
+		{"[h]:mm:ss", kindDate},
+		{"[Red]#,##0", kindNumber},
+		{`"days "0`, kindNumber},
+		{`0\d`, kindNumber},
+	}
+	for _, tt := range tests {
+		if got := codeKind(tt.code); got != tt.want {
+			t.Errorf("codeKind(%q) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		serial   float64
+		date1904 bool
+		want     string
+	}{
+		{1, false, "1900-01-01"},
+		{59, false, "1900-02-28"},
+		{61, false, "1900-03-01"},
+		{43435, false, "2018-12-01"},
+		{43435.75, false, "2018-12-01 18:00:00"},
+		{0, true, "1904-01-01"},
+	}
+	for _, tt := range tests {
+		if got := (styles{date1904: tt.date1904}).formatDate(tt.serial, ""); got != tt.want {
+			t.Errorf("formatDate(%v, 1904=%t) = %q, want %q", tt.serial, tt.date1904, got, tt.want)
+		}
+	}
+}